@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -30,6 +31,11 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 
 	command := app.NewSubCommand("build", "Builds the application")
 
+	command.StringFlag("type", "Output type: desktop or server", &outputType)
+
+	buildMode := ""
+	command.StringFlag("buildmode", "Set to c-shared to build a shared library and C header instead of an executable", &buildMode)
+
 	// Setup noPackage flag
 	noPackage := false
 	command.BoolFlag("noPackage", "Skips platform specific packaging", &noPackage)
@@ -37,9 +43,21 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 	compilerCommand := "go"
 	command.StringFlag("compiler", "Use a different go compiler to build, eg go1.15beta1", &compilerCommand)
 
+	minGoVersion := ""
+	command.StringFlag("mingoversion", "Fail early if the compiler is older than this Go version, eg 1.21", &minGoVersion)
+
 	skipModTidy := false
 	command.BoolFlag("m", "Skip mod tidy before compile", &skipModTidy)
 
+	modTidyMode := ""
+	command.StringFlag("modtidy", "How to use go mod tidy: run (default), skip or verify the module is tidy without changing it", &modTidyMode)
+
+	offline := false
+	command.BoolFlag("offline", "Build without network access, using only the cached Go modules and frontend packages", &offline)
+
+	useVendor := false
+	command.BoolFlag("vendor", "Build from the vendor directory", &useVendor)
+
 	compress := false
 	command.BoolFlag("upx", "Compress final binary with UPX (if installed)", &compress)
 
@@ -66,27 +84,72 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 	verbosity := 1
 	command.IntFlag("v", "Verbosity level (0 - silent, 1 - default, 2 - verbose)", &verbosity)
 
+	suppressNotices := false
+	command.BoolFlag("nonotices", "Don't print notices about experimental features", &suppressNotices)
+
 	// ldflags to pass to `go`
 	ldflags := ""
 	command.StringFlag("ldflags", "optional ldflags", &ldflags)
 
+	injectBuildInfo := false
+	command.BoolFlag("buildinfo", "Inject the git commit, dirty state and build time into the commit, dirty and buildTime variables", &injectBuildInfo)
+
+	buildInfoPrefix := ""
+	command.StringFlag("buildinfoprefix", "The package of the build info variables, eg github.com/me/app/version. Defaults to main", &buildInfoPrefix)
+
+	extraGoFlags := ""
+	command.StringFlag("extragoflags", "Space separated flags appended to `go build`, eg -gcflags=all=-l", &extraGoFlags)
+
 	// tags to pass to `go`
 	tags := ""
 	command.StringFlag("tags", "Build tags to pass to Go compiler. Must be quoted. Space or comma (but not both) separated", &tags)
 
+	bindingsTagsFlag := ""
+	command.StringFlag("bindingstags", "Build tags to generate the bindings with instead of -tags. Must be quoted. Space or comma (but not both) separated", &bindingsTagsFlag)
+
 	outputFilename := ""
 	command.StringFlag("o", "Output filename", &outputFilename)
 
+	outputNameTemplate := ""
+	command.StringFlag("outputtemplate", "Output filename template with {name}, {version}, {platform}, {arch} and {mode} placeholders, eg {name}-{version}-{platform}-{arch}", &outputNameTemplate)
+
+	entryPoint := ""
+	command.StringFlag("entrypoint", "Directory of the main package to build, eg cmd/helper. Defaults to the project root", &entryPoint)
+
 	// Clean bin directory
 	cleanBinDirectory := false
 	command.BoolFlag("clean", "Clean the bin directory before building", &cleanBinDirectory)
 
+	backupBeforeClean := false
+	command.BoolFlag("backupclean", "Move the bin directory to a timestamped bin.bak-<time> directory instead of deleting it when cleaning", &backupBeforeClean)
+
+	cleanBackupsToKeep := 0
+	command.IntFlag("keepbackups", "Number of bin directory backups to keep when backing up before cleaning. 0 keeps them all", &cleanBackupsToKeep)
+
 	webview2 := "download"
 	command.StringFlag("webview2", "WebView2 installer strategy: download,embed,browser,error.", &webview2)
 
 	skipFrontend := false
 	command.BoolFlag("s", "Skips building the frontend", &skipFrontend)
 
+	prebuiltFrontend := ""
+	command.StringFlag("prebuiltfrontend", "Copy this already built frontend into the embedded frontend directory. Requires -s", &prebuiltFrontend)
+
+	frontendArchive := false
+	command.BoolFlag("frontendarchive", "Archive the built frontend into the .tar or .tar.gz embedded by the project instead of embedding its files", &frontendArchive)
+
+	precompressAssets := ""
+	command.StringFlag("precompress", "Precompress the frontend assets before embedding them: none, gzip or brotli", &precompressAssets)
+
+	allowEmptyEmbeds := false
+	command.BoolFlag("allowemptyembeds", "Compile even if the embedded frontend directory has no index.html", &allowEmptyEmbeds)
+
+	frontendRetries := 0
+	command.IntFlag("frontendretries", "Number of times to retry a failed frontend build", &frontendRetries)
+
+	frontendPackageManager := ""
+	command.StringFlag("packagemanager", "Frontend package manager: npm, pnpm, bun or yarn. Detected from the lockfile by default", &frontendPackageManager)
+
 	forceBuild := false
 	command.BoolFlag("f", "Force build application", &forceBuild)
 
@@ -102,24 +165,126 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 	trimpath := false
 	command.BoolFlag("trimpath", "Remove all file system paths from the resulting executable", &trimpath)
 
+	failOnWarnings := false
+	command.BoolFlag("failonwarnings", "Fail the build if the compiler reports any warnings", &failOnWarnings)
+
+	trackSize := false
+	command.BoolFlag("tracksize", "Print the binary size and its change since the last build", &trackSize)
+
+	emitResultJSON := false
+	command.BoolFlag("resultjson", "Print a WAILS_BUILD_RESULT={...} line with the built binary and bundle for tools", &emitResultJSON)
+
+	autoCleanCache := false
+	command.BoolFlag("autocleancache", "If the compile fails because the go build cache is stale, clean it and retry once", &autoCleanCache)
+
+	runVet := false
+	command.BoolFlag("vet", "Run go vet with the build tags before compiling and fail the build on any problems", &runVet)
+
+	reproducible := false
+	command.BoolFlag("reproducible", "Produce byte-identical builds of the same commit. Uses SOURCE_DATE_EPOCH or the last commit time", &reproducible)
+
 	raceDetector := false
 	command.BoolFlag("race", "Build with Go's race detector", &raceDetector)
 
+	compileParallelism := 0
+	command.IntFlag("parallel", "Number of packages compiled in parallel (go build -p). 0 uses Go's default", &compileParallelism)
+
+	cgo := ""
+	command.StringFlag("cgo", "Set CGO_ENABLED for the build: true or false. Defaults to enabled except on Windows", &cgo)
+
+	cc := ""
+	command.StringFlag("cc", "The C compiler used by CGO, eg x86_64-w64-mingw32-gcc. ${arch} is replaced with the arch", &cc)
+
+	cxx := ""
+	command.StringFlag("cxx", "The C++ compiler used by CGO. ${arch} is replaced with the arch", &cxx)
+
 	windowsConsole := false
 	command.BoolFlag("windowsconsole", "Keep the console when building for Windows", &windowsConsole)
 
+	windowsMetadata := false
+	command.BoolFlag("windowsmetadata", "Embed the icon, manifest and version info when building for Windows with -noPackage", &windowsMetadata)
+
+	macMinVersion := ""
+	command.StringFlag("macminversion", "The minimum macOS version to build for, eg 11.0", &macMinVersion)
+
+	macSigningIdentity := ""
+	command.StringFlag("macsign", "Identity to sign the macOS application bundle with", &macSigningIdentity)
+
+	macEntitlements := ""
+	command.StringFlag("macentitlements", "Entitlements file to sign the macOS application bundle with", &macEntitlements)
+
+	lipoPath := ""
+	command.StringFlag("lipo", "The lipo used to create darwin universal binaries, eg llvm-lipo. Defaults to lipo if installed, else they are merged without it", &lipoPath)
+
+	optimizeFor := ""
+	command.StringFlag("optimize", "Apply a build profile: size (strip, trimpath, UPX) or speed (GOAMD64=v3)", &optimizeFor)
+
+	goarm := ""
+	command.StringFlag("goarm", "The GOARM version for 32-bit arm builds: 5, 6 or 7", &goarm)
+
+	pgoProfile := ""
+	command.StringFlag("pgo", "Profile for profile-guided optimization, or auto to use default.pgo in the main package. Requires Go 1.21", &pgoProfile)
+
+	generateChecksums := false
+	command.BoolFlag("checksums", "Write a SHA256SUMS file of the built binaries and packages", &generateChecksums)
+
+	gpgSigningKey := ""
+	command.StringFlag("gpgkey", "GPG key to sign the SHA256SUMS file with", &gpgSigningKey)
+
+	keepUniversalSlices := false
+	command.BoolFlag("keepslices", "Keep the amd64 and arm64 binaries of a darwin universal build", &keepUniversalSlices)
+
+	notarizeProfile := ""
+	command.StringFlag("notarize", "notarytool keychain profile to notarize the signed macOS application bundle with", &notarizeProfile)
+
+	zipBundle := false
+	command.BoolFlag("zipbundle", "Zip the macOS application bundle to <name>.app.zip", &zipBundle)
+
+	linuxPackageFormat := ""
+	command.StringFlag("linuxpackage", "Package Linux builds as an appimage or deb. Requires appimagetool or dpkg-deb", &linuxPackageFormat)
+
+	windowsManifest := ""
+	command.StringFlag("windowsmanifest", "Custom application manifest to embed when building for Windows", &windowsManifest)
+
+	windowsIcon := ""
+	command.StringFlag("windowsicon", "The Windows icon, a .ico or a .png to convert. Defaults to build/windows/icon.ico", &windowsIcon)
+
+	macIcon := ""
+	command.StringFlag("macicon", "The macOS icon, a .icns or a .png to convert. Defaults to build/appicon.png", &macIcon)
+
+	linuxIcon := ""
+	command.StringFlag("linuxicon", "The .png icon of Linux packages. Defaults to build/appicon.png", &linuxIcon)
+
 	obfuscated := false
 	command.BoolFlag("obfuscated", "Code obfuscation of bound Wails methods", &obfuscated)
 
 	garbleargs := "-literals -tiny -seed=random"
 	command.StringFlag("garbleargs", "Arguments to pass to garble", &garbleargs)
 
+	garbleExclude := ""
+	command.StringFlag("garbleexclude", "Comma separated modules that garble should not obfuscate", &garbleExclude)
+
 	dryRun := false
 	command.BoolFlag("dryrun", "Dry run, prints the config for the command that would be executed", &dryRun)
 
+	minFreeDiskMB := build.DefaultMinFreeDiskBytes / (1024 * 1024)
+	command.IntFlag("minfreedisk", "Minimum free disk space in MB needed to start a build. 0 to skip the check", &minFreeDiskMB)
+
 	skipBindings := false
 	command.BoolFlag("skipbindings", "Skips generation of bindings", &skipBindings)
 
+	bindingsDir := ""
+	command.StringFlag("bindingsdir", "Directory to generate the bindings in, relative to the project. Defaults to the wailsjsdir", &bindingsDir)
+
+	bindingsSchema := ""
+	command.StringFlag("bindingsschema", "Write a JSON description of the bound methods and models to this file", &bindingsSchema)
+
+	checkBindings := false
+	command.BoolFlag("checkbindings", "Fails the build if the generated bindings are out of date, rather than updating them", &checkBindings)
+
+	embedPlaceholder := build.DefaultEmbedPlaceholderName
+	command.StringFlag("embedplaceholder", "Placeholder file to create in empty embed directories. Empty to skip", &embedPlaceholder)
+
 	command.Action(func() error {
 
 		quiet := verbosity == 0
@@ -148,6 +313,19 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 		if err != nil {
 			return err
 		}
+		bindingsTags, err := buildtags.Parse(bindingsTagsFlag)
+		if err != nil {
+			return err
+		}
+
+		var cgoEnabled *bool
+		if cgo != "" {
+			enabled, err := strconv.ParseBool(cgo)
+			if err != nil {
+				return fmt.Errorf("invalid option for flag 'cgo': %s", cgo)
+			}
+			cgoEnabled = &enabled
+		}
 
 		// Webview2 installer strategy (download by default)
 		wv2rtstrategy := ""
@@ -188,31 +366,98 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 			return err
 		}
 
+		var obfuscationExclude []string
+		if garbleExclude != "" {
+			obfuscationExclude = strings.Split(garbleExclude, ",")
+		}
+
+		compressMethod := build.CompressNone
+		if compress {
+			compressMethod = build.CompressUPX
+		} else if optimizeFor != "" {
+			// Let the profile choose the compression
+			compressMethod = ""
+		}
+
 		// Create BuildOptions
 		buildOptions := &build.Options{
-			Logger:            logger,
-			OutputType:        outputType,
-			OutputFile:        outputFilename,
-			CleanBinDirectory: cleanBinDirectory,
-			Mode:              mode,
-			Pack:              !noPackage,
-			LDFlags:           ldflags,
-			Compiler:          compilerCommand,
-			SkipModTidy:       skipModTidy,
-			Verbosity:         verbosity,
-			ForceBuild:        forceBuild,
-			IgnoreFrontend:    skipFrontend,
-			Compress:          compress,
-			CompressFlags:     compressFlags,
-			UserTags:          userTags,
-			WebView2Strategy:  wv2rtstrategy,
-			TrimPath:          trimpath,
-			RaceDetector:      raceDetector,
-			WindowsConsole:    windowsConsole,
-			Obfuscated:        obfuscated,
-			GarbleArgs:        garbleargs,
-			SkipBindings:      skipBindings,
-			ProjectData:       projectOptions,
+			Logger:                 logger,
+			OutputType:             outputType,
+			BuildMode:              buildMode,
+			OutputFile:             outputFilename,
+			OutputNameTemplate:     outputNameTemplate,
+			EntryPoint:             entryPoint,
+			CleanBinDirectory:      cleanBinDirectory,
+			BackupBeforeClean:      backupBeforeClean,
+			CleanBackupsToKeep:     cleanBackupsToKeep,
+			Mode:                   mode,
+			Pack:                   !noPackage,
+			LDFlags:                ldflags,
+			InjectBuildInfo:        injectBuildInfo,
+			BuildInfoVarPrefix:     buildInfoPrefix,
+			ExtraGoFlags:           strings.Fields(extraGoFlags),
+			Compiler:               compilerCommand,
+			MinGoVersion:           minGoVersion,
+			SkipModTidy:            skipModTidy,
+			ModTidyMode:            modTidyMode,
+			Offline:                offline,
+			UseVendor:              useVendor,
+			Verbosity:              verbosity,
+			SuppressNotices:        suppressNotices,
+			ForceBuild:             forceBuild,
+			IgnoreFrontend:         skipFrontend,
+			PrebuiltFrontendDir:    prebuiltFrontend,
+			FrontendArchive:        frontendArchive,
+			PrecompressAssets:      precompressAssets,
+			AllowEmptyEmbeds:       allowEmptyEmbeds,
+			FrontendBuildRetries:   frontendRetries,
+			FrontendPackageManager: frontendPackageManager,
+			AutoCleanCacheOnStale:  autoCleanCache,
+			CompressMethod:         compressMethod,
+			CompressFlags:          compressFlags,
+			UserTags:               userTags,
+			BindingsTags:           bindingsTags,
+			WebView2Strategy:       wv2rtstrategy,
+			TrimPath:               trimpath,
+			Reproducible:           reproducible,
+			FailOnWarnings:         failOnWarnings,
+			TrackSize:              trackSize,
+			EmitResultJSON:         emitResultJSON,
+			RunVet:                 runVet,
+			OptimizeFor:            optimizeFor,
+			GOARM:                  goarm,
+			PGOProfile:             pgoProfile,
+			RaceDetector:           raceDetector,
+			CompileParallelism:     compileParallelism,
+			CGOEnabled:             cgoEnabled,
+			CC:                     cc,
+			CXX:                    cxx,
+			WindowsConsole:         windowsConsole,
+			EmbedWindowsMetadata:   windowsMetadata,
+			WindowsManifestFile:    windowsManifest,
+			WindowsIconFile:        windowsIcon,
+			MacIconFile:            macIcon,
+			LinuxIconFile:          linuxIcon,
+			MacMinVersion:          macMinVersion,
+			MacSigningIdentity:     macSigningIdentity,
+			MacEntitlementsFile:    macEntitlements,
+			NotarizeProfile:        notarizeProfile,
+			ZipBundle:              zipBundle,
+			LinuxPackageFormat:     linuxPackageFormat,
+			LipoPath:               lipoPath,
+			KeepUniversalSlices:    keepUniversalSlices,
+			GenerateChecksums:      generateChecksums,
+			GPGSigningKey:          gpgSigningKey,
+			MinFreeDiskBytes:       uint64(minFreeDiskMB) * 1024 * 1024,
+			Obfuscated:             obfuscated,
+			GarbleArgs:             garbleargs,
+			ObfuscationExclude:     obfuscationExclude,
+			SkipBindings:           skipBindings,
+			DryRun:                 dryRun,
+			EmbedPlaceholderName:   embedPlaceholder,
+			NoEmbedPlaceholder:     embedPlaceholder == "",
+			BindingsCheckOnly:      checkBindings,
+			ProjectData:            projectOptions,
 		}
 
 		// Start a new tabwriter
@@ -232,7 +477,7 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 				_, _ = fmt.Fprintf(w, "Garble Args: \t%s\n", buildOptions.GarbleArgs)
 			}
 			_, _ = fmt.Fprintf(w, "Skip Frontend: \t%t\n", skipFrontend)
-			_, _ = fmt.Fprintf(w, "Compress: \t%t\n", buildOptions.Compress)
+			_, _ = fmt.Fprintf(w, "Compress: \t%s\n", buildOptions.CompressMethod)
 			_, _ = fmt.Fprintf(w, "Package: \t%t\n", buildOptions.Pack)
 			_, _ = fmt.Fprintf(w, "Clean Bin Dir: \t%t\n", buildOptions.CleanBinDirectory)
 			_, _ = fmt.Fprintf(w, "LDFlags: \t\"%s\"\n", buildOptions.LDFlags)
@@ -259,6 +504,9 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 			"darwin/amd64",
 			"darwin/arm64",
 			"darwin/universal",
+			"freebsd",
+			"freebsd/amd64",
+			"freebsd/arm64",
 			"linux",
 			"linux/amd64",
 			"linux/arm64",
@@ -304,6 +552,7 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 			if compress && platform == "darwin/universal" {
 				logger.Println("Warning: compress flag unsupported for universal binaries. Ignoring.")
 				compress = false
+				buildOptions.CompressMethod = build.CompressNone
 			}
 
 			switch buildOptions.Platform {
@@ -312,6 +561,11 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 					logger.Println("Crosscompiling to Linux not currently supported.\n")
 					return
 				}
+			case "freebsd":
+				if runtime.GOOS != "freebsd" {
+					logger.Println("Crosscompiling to FreeBSD not currently supported.\n")
+					return
+				}
 			case "darwin":
 				if runtime.GOOS != "darwin" {
 					logger.Println("Crosscompiling to Mac not currently supported.\n")
@@ -320,9 +574,7 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 				macTargets := targets.Filter(func(platform string) bool {
 					return strings.HasPrefix(platform, "darwin")
 				})
-				if macTargets.Length() == 2 {
-					buildOptions.BundleName = fmt.Sprintf("%s-%s.app", desiredFilename, buildOptions.Arch)
-				}
+				buildOptions.BundleArchSuffix = macTargets.Length() == 2
 			}
 
 			if targets.Length() > 1 {
@@ -330,14 +582,17 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 				switch buildOptions.Platform {
 				case "windows":
 					desiredFilename = fmt.Sprintf("%s-%s", desiredFilename, buildOptions.Arch)
-				case "linux", "darwin":
+				case "linux", "darwin", "freebsd":
 					desiredFilename = fmt.Sprintf("%s-%s-%s", desiredFilename, buildOptions.Platform, buildOptions.Arch)
 				}
 			}
 			if buildOptions.Platform == "windows" {
 				desiredFilename += ".exe"
 			}
-			buildOptions.OutputFile = desiredFilename
+			// The template names each target itself
+			if outputNameTemplate == "" {
+				buildOptions.OutputFile = desiredFilename
+			}
 
 			if outputFilename != "" {
 				buildOptions.OutputFile = outputFilename
@@ -367,6 +622,11 @@ func AddBuildSubcommand(app *clir.Cli, w io.Writer) {
 
 				outputBinaries[buildOptions.Platform+"/"+buildOptions.Arch] = compiledBinary
 			} else {
+				if _, err := build.Build(buildOptions); err != nil {
+					logger.Println("Error: %s", err.Error())
+					targetErr = err
+					return
+				}
 				logger.Println("Dry run: skipped build.")
 			}
 		})
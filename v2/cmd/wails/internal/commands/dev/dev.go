@@ -187,7 +187,8 @@ func AddSubcommand(app *clir.Cli, w io.Writer) error {
 				LogGreen("Generating Bindings...")
 			}
 			stdout, err := bindings.GenerateBindings(bindings.Options{
-				Tags: buildOptions.UserTags,
+				Tags:     buildOptions.UserTags,
+				Compiler: buildOptions.Compiler,
 			})
 			if err != nil {
 				return err
@@ -340,18 +341,23 @@ func defaultDevFlags() devFlags {
 // generateBuildOptions creates a build.Options using the flags
 func generateBuildOptions(flags devFlags) *build.Options {
 	result := &build.Options{
-		OutputType:     "dev",
-		Mode:           build.Dev,
-		Arch:           runtime.GOARCH,
-		Pack:           true,
-		Platform:       runtime.GOOS,
-		LDFlags:        flags.ldflags,
-		Compiler:       flags.compilerCommand,
-		ForceBuild:     flags.forceBuild,
-		IgnoreFrontend: flags.skipFrontend,
-		Verbosity:      flags.verbosity,
-		WailsJSDir:     flags.wailsjsdir,
-		RaceDetector:   flags.raceDetector,
+		OutputType:           "dev",
+		Mode:                 build.Dev,
+		Arch:                 runtime.GOARCH,
+		Pack:                 true,
+		Platform:             runtime.GOOS,
+		LDFlags:              flags.ldflags,
+		Compiler:             flags.compilerCommand,
+		ForceBuild:           flags.forceBuild,
+		IgnoreFrontend:       flags.skipFrontend,
+		Verbosity:            flags.verbosity,
+		WailsJSDir:           flags.wailsjsdir,
+		RaceDetector:         flags.raceDetector,
+		EmbedPlaceholderName: build.DefaultEmbedPlaceholderName,
+		// The application's output is shown by wails dev
+		WindowsConsole: true,
+		// The frontend may be served by the dev server rather than the embedded assets
+		AllowEmptyEmbeds: true,
 	}
 
 	return result
@@ -2,9 +2,10 @@ package generate
 
 import (
 	"github.com/leaanthony/clir"
-	"github.com/wailsapp/wails/v2/pkg/commands/bindings"
+	"github.com/wailsapp/wails/v2/pkg/commands/build"
 	"github.com/wailsapp/wails/v2/pkg/commands/buildtags"
 	"io"
+	"os"
 )
 
 // AddModuleCommand adds the `module` subcommand for the `generate` command
@@ -21,14 +22,12 @@ func AddModuleCommand(app *clir.Cli, parent *clir.Command, w io.Writer) error {
 			return err
 		}
 
-		_, err = bindings.GenerateBindings(bindings.Options{
-			Tags: buildTags,
-		})
+		cwd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return build.GenerateBindingsOnly(cwd, buildTags, "")
 	})
 	return nil
 }
@@ -44,6 +44,7 @@ require (
 	github.com/sabhiram/go-gitignore v0.0.0-20210923224102-525f6e181f06
 	github.com/samber/lo v1.27.1
 	github.com/stretchr/testify v1.7.1
+	golang.org/x/sync v0.1.0
 	golang.org/x/tools v0.1.12
 )
 
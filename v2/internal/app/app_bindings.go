@@ -31,6 +31,15 @@ func (a *App) Run() error {
 	if err != nil {
 		return err
 	}
+
+	// WAILS_BINDINGS_SCHEMA is set by the bindings command when a schema file is wanted
+	if schemaFile := os.Getenv("WAILS_BINDINGS_SCHEMA"); schemaFile != "" {
+		schema, err := appBindings.GenerateSchema()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(schemaFile, schema, 0644)
+	}
 	return nil
 }
 
@@ -60,7 +69,13 @@ func generateBindings(bindings *binding.Bindings) error {
 		return err
 	}
 
-	wailsjsbasedir := filepath.Join(projectConfig.GetWailsJSDir(), "wailsjs")
+	// WAILS_JS_DIR is set by the bindings command to override the project's wailsjsdir
+	wailsjsdir := os.Getenv("WAILS_JS_DIR")
+	if wailsjsdir == "" {
+		wailsjsdir = projectConfig.GetWailsJSDir()
+	}
+
+	wailsjsbasedir := filepath.Join(wailsjsdir, "wailsjs")
 
 	runtimeDir := filepath.Join(wailsjsbasedir, "runtime")
 	_ = os.RemoveAll(runtimeDir)
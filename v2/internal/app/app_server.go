@@ -0,0 +1,114 @@
+//go:build server
+
+package app
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
+	"github.com/wailsapp/wails/v2/internal/frontend/server"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/internal/menumanager"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+const defaultServerAddr = "localhost:34115"
+
+func (a *App) Run() error {
+	err := a.frontend.Run(a.ctx)
+	if err != nil {
+		return err
+	}
+	a.frontend.RunMainLoop()
+	a.frontend.WindowClose()
+	if a.shutdownCallback != nil {
+		a.shutdownCallback(a.ctx)
+	}
+	return nil
+}
+
+// CreateApp creates the app!
+func CreateApp(appoptions *options.App) (*App, error) {
+	var err error
+
+	ctx := context.Background()
+
+	// Merge default options
+	options.MergeDefaults(appoptions)
+
+	debug := IsDebug()
+	ctx = context.WithValue(ctx, "debug", debug)
+
+	// Set up logger
+	myLogger := logger.New(appoptions.Logger)
+	if IsDebug() {
+		myLogger.SetLogLevel(appoptions.LogLevel)
+	} else {
+		myLogger.SetLogLevel(appoptions.LogLevelProduction)
+	}
+	ctx = context.WithValue(ctx, "logger", myLogger)
+	ctx = context.WithValue(ctx, "obfuscated", IsObfuscated())
+
+	// Check for the server address and allowed origins in the environment, then the CLI flags
+	serverFlags := flag.NewFlagSet("server", flag.ContinueOnError)
+	serverAddrFlag := serverFlags.String("serveraddr", defaultServerAddr, "Address to bind the server to")
+	allowedOriginsFlag := serverFlags.String("allowedorigins", "", "Comma separated origins, other than the served host, allowed to connect to the IPC websocket")
+	// Parse args but ignore errors so the app can accept its own args.
+	_ = serverFlags.Parse(os.Args[1:])
+	serverAddr := os.Getenv("serveraddr")
+	if serverAddr == "" {
+		serverAddr = *serverAddrFlag
+	}
+	allowedOrigins := os.Getenv("allowedorigins")
+	if allowedOrigins == "" {
+		allowedOrigins = *allowedOriginsFlag
+	}
+	ctx = context.WithValue(ctx, "serveraddr", serverAddr)
+	ctx = context.WithValue(ctx, "allowedorigins", allowedOrigins)
+
+	// Create the menu manager
+	menuManager := menumanager.NewManager()
+
+	// Process the application menu
+	if appoptions.Menu != nil {
+		err = menuManager.SetApplicationMenu(appoptions.Menu)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create binding exemptions - Ugly hack. There must be a better way
+	bindingExemptions := []interface{}{
+		appoptions.OnStartup,
+		appoptions.OnShutdown,
+		appoptions.OnDomReady,
+		appoptions.OnBeforeClose,
+	}
+	appBindings := binding.NewBindings(myLogger, appoptions.Bind, bindingExemptions, IsObfuscated())
+	eventHandler := runtime.NewEvents(myLogger)
+	ctx = context.WithValue(ctx, "events", eventHandler)
+	ctx = context.WithValue(ctx, "buildtype", "server")
+
+	messageDispatcher := dispatcher.NewDispatcher(ctx, myLogger, appBindings, eventHandler)
+	appFrontend := server.NewFrontend(ctx, appoptions, myLogger, appBindings, messageDispatcher)
+	eventHandler.AddFrontend(appFrontend)
+
+	ctx = context.WithValue(ctx, "frontend", appFrontend)
+	result := &App{
+		ctx:              ctx,
+		frontend:         appFrontend,
+		logger:           myLogger,
+		menuManager:      menuManager,
+		startupCallback:  appoptions.OnStartup,
+		shutdownCallback: appoptions.OnShutdown,
+		debug:            debug,
+		options:          appoptions,
+	}
+
+	return result, nil
+
+}
@@ -0,0 +1,149 @@
+package binding
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema is a language agnostic description of the bound methods and the structs they use.
+// Everything is sorted by name so that the output is stable.
+type Schema struct {
+	Packages []*SchemaPackage `json:"packages"`
+	Models   []*SchemaModel   `json:"models"`
+}
+
+// SchemaPackage describes the bound structs of a Go package
+type SchemaPackage struct {
+	Name    string          `json:"name"`
+	Structs []*SchemaStruct `json:"structs"`
+}
+
+// SchemaStruct describes a bound struct and its methods
+type SchemaStruct struct {
+	Name    string          `json:"name"`
+	Methods []*SchemaMethod `json:"methods"`
+}
+
+// SchemaMethod describes a bound method
+type SchemaMethod struct {
+	Name    string       `json:"name"`
+	Inputs  []*Parameter `json:"inputs"`
+	Outputs []*Parameter `json:"outputs"`
+}
+
+// SchemaModel describes a struct used by the bound methods
+type SchemaModel struct {
+	Package string         `json:"package"`
+	Name    string         `json:"name"`
+	Fields  []*SchemaField `json:"fields"`
+}
+
+// SchemaField describes a field of a model. JSONName is the name of the field when serialised
+type SchemaField struct {
+	Name     string `json:"name"`
+	JSONName string `json:"jsonName"`
+	Type     string `json:"type"`
+}
+
+// Schema returns the schema of the bindings
+func (b *Bindings) Schema() *Schema {
+	result := &Schema{
+		Packages: []*SchemaPackage{},
+		Models:   []*SchemaModel{},
+	}
+
+	b.db.lock.RLock()
+	for packageName, structs := range b.db.store {
+		schemaPackage := &SchemaPackage{
+			Name:    packageName,
+			Structs: []*SchemaStruct{},
+		}
+		for structName, methods := range structs {
+			schemaStruct := &SchemaStruct{
+				Name:    structName,
+				Methods: []*SchemaMethod{},
+			}
+			for methodName, method := range methods {
+				schemaStruct.Methods = append(schemaStruct.Methods, &SchemaMethod{
+					Name:    methodName,
+					Inputs:  nonNilParameters(method.Inputs),
+					Outputs: nonNilParameters(method.Outputs),
+				})
+			}
+			sort.Slice(schemaStruct.Methods, func(i, j int) bool {
+				return schemaStruct.Methods[i].Name < schemaStruct.Methods[j].Name
+			})
+			schemaPackage.Structs = append(schemaPackage.Structs, schemaStruct)
+		}
+		sort.Slice(schemaPackage.Structs, func(i, j int) bool {
+			return schemaPackage.Structs[i].Name < schemaPackage.Structs[j].Name
+		})
+		result.Packages = append(result.Packages, schemaPackage)
+	}
+	b.db.lock.RUnlock()
+	sort.Slice(result.Packages, func(i, j int) bool {
+		return result.Packages[i].Name < result.Packages[j].Name
+	})
+
+	for packageName, structs := range b.structsToGenerateTS {
+		for structName, s := range structs {
+			result.Models = append(result.Models, &SchemaModel{
+				Package: packageName,
+				Name:    structName,
+				Fields:  schemaFields(reflect.TypeOf(s)),
+			})
+		}
+	}
+	sort.Slice(result.Models, func(i, j int) bool {
+		if result.Models[i].Package != result.Models[j].Package {
+			return result.Models[i].Package < result.Models[j].Package
+		}
+		return result.Models[i].Name < result.Models[j].Name
+	})
+
+	return result
+}
+
+// GenerateSchema returns the schema of the bindings as indented JSON
+func (b *Bindings) GenerateSchema() ([]byte, error) {
+	return json.MarshalIndent(b.Schema(), "", "  ")
+}
+
+func nonNilParameters(parameters []*Parameter) []*Parameter {
+	if parameters == nil {
+		return []*Parameter{}
+	}
+	return parameters
+}
+
+// schemaFields returns the exported fields of the given struct type, in declaration order
+func schemaFields(structType reflect.Type) []*SchemaField {
+	if hasElements(structType) {
+		structType = structType.Elem()
+	}
+	result := []*SchemaField{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		jsonName := field.Name
+		if jsonTag := field.Tag.Get("json"); jsonTag != "" {
+			tagName := strings.Split(jsonTag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				jsonName = tagName
+			}
+		}
+		result = append(result, &SchemaField{
+			Name:     field.Name,
+			JSONName: jsonName,
+			Type:     field.Type.String(),
+		})
+	}
+	return result
+}
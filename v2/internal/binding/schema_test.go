@@ -0,0 +1,66 @@
+package binding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wailsapp/wails/v2/internal/logger"
+)
+
+type SchemaForTest struct {
+}
+
+func (s *SchemaForTest) Save(name string, b B) (A, error) {
+	return A{}, nil
+}
+
+func (s *SchemaForTest) Count() int {
+	return 0
+}
+
+func TestBindings_Schema(t *testing.T) {
+	testBindings := NewBindings(logger.New(nil), []interface{}{&SchemaForTest{}}, []interface{}{}, false)
+
+	schema := testBindings.Schema()
+
+	require.Len(t, schema.Packages, 1)
+	assert.Equal(t, "binding", schema.Packages[0].Name)
+	require.Len(t, schema.Packages[0].Structs, 1)
+	assert.Equal(t, "SchemaForTest", schema.Packages[0].Structs[0].Name)
+
+	methods := schema.Packages[0].Structs[0].Methods
+	require.Len(t, methods, 2)
+	assert.Equal(t, "Count", methods[0].Name)
+	assert.Empty(t, methods[0].Inputs)
+	assert.Equal(t, []*Parameter{{TypeName: "int"}}, stripReflectTypes(methods[0].Outputs))
+	assert.Equal(t, "Save", methods[1].Name)
+	assert.Equal(t, []*Parameter{{TypeName: "string"}, {TypeName: "binding.B"}}, stripReflectTypes(methods[1].Inputs))
+	assert.Equal(t, []*Parameter{{TypeName: "binding.A"}, {TypeName: "error"}}, stripReflectTypes(methods[1].Outputs))
+
+	require.Len(t, schema.Models, 2)
+	assert.Equal(t, &SchemaModel{
+		Package: "binding",
+		Name:    "A",
+		Fields:  []*SchemaField{{Name: "B", JSONName: "B", Type: "binding.B"}},
+	}, schema.Models[0])
+	assert.Equal(t, &SchemaModel{
+		Package: "binding",
+		Name:    "B",
+		Fields:  []*SchemaField{{Name: "Name", JSONName: "name", Type: "string"}},
+	}, schema.Models[1])
+
+	first, err := testBindings.GenerateSchema()
+	require.NoError(t, err)
+	second, err := testBindings.GenerateSchema()
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+}
+
+func stripReflectTypes(parameters []*Parameter) []*Parameter {
+	var result []*Parameter
+	for _, parameter := range parameters {
+		result = append(result, &Parameter{Name: parameter.Name, TypeName: parameter.TypeName})
+	}
+	return result
+}
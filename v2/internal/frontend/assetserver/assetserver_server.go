@@ -0,0 +1,28 @@
+//go:build server
+// +build server
+
+package assetserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
+)
+
+/*
+The assetserver for the server mode.
+There is no webview, so the websocket based IPC script is always injected into `index.html`.
+*/
+func NewServerAssetServer(ctx context.Context, handler http.Handler, bindingsJSON string) (*AssetServer, error) {
+	result, err := NewAssetServerWithHandler(ctx, handler, bindingsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ipcJS = func(_ *http.Request) []byte {
+		return runtime.WebsocketIPC
+	}
+
+	return result, nil
+}
@@ -0,0 +1,327 @@
+//go:build server
+// +build server
+
+// Package server provides a headless frontend that serves the application
+// assets and bound methods over HTTP and WebSockets instead of a WebView.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend"
+	"github.com/wailsapp/wails/v2/internal/frontend/assetserver"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/menu"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"golang.org/x/net/websocket"
+)
+
+type Screen = frontend.Screen
+
+var errNotSupported = fmt.Errorf("not supported when running as a server")
+
+type Server struct {
+	server           *echo.Echo
+	ctx              context.Context
+	appoptions       *options.App
+	logger           *logger.Logger
+	appBindings      *binding.Bindings
+	dispatcher       frontend.Dispatcher
+	socketMutex      sync.Mutex
+	websocketClients map[*websocket.Conn]*sync.Mutex
+
+	serverAddr     string
+	allowedOrigins []string
+	quit           chan struct{}
+	quitOnce       sync.Once
+}
+
+func NewFrontend(ctx context.Context, appoptions *options.App, myLogger *logger.Logger, appBindings *binding.Bindings, dispatcher frontend.Dispatcher) *Server {
+	result := &Server{
+		ctx:              ctx,
+		appoptions:       appoptions,
+		logger:           myLogger,
+		appBindings:      appBindings,
+		dispatcher:       dispatcher,
+		server:           echo.New(),
+		websocketClients: make(map[*websocket.Conn]*sync.Mutex),
+		quit:             make(chan struct{}),
+	}
+
+	result.serverAddr, _ = ctx.Value("serveraddr").(string)
+	if allowedOrigins, _ := ctx.Value("allowedorigins").(string); allowedOrigins != "" {
+		for _, origin := range strings.Split(allowedOrigins, ",") {
+			if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+				result.allowedOrigins = append(result.allowedOrigins, origin)
+			}
+		}
+	}
+	result.server.HideBanner = true
+	result.server.HidePort = true
+	return result
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	s.ctx = ctx
+
+	s.server.GET("/wails/ipc", s.handleIPCWebSocket)
+
+	assetHandler, err := assetserver.NewAssetHandler(ctx, assetserver.BuildAssetServerConfig(s.appoptions))
+	if err != nil {
+		return err
+	}
+
+	bindingsJSON, err := s.appBindings.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	assetServer, err := assetserver.NewServerAssetServer(ctx, assetHandler, bindingsJSON)
+	if err != nil {
+		return err
+	}
+
+	s.server.Any("/*", func(c echo.Context) error {
+		assetServer.ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
+
+	go func() {
+		err := s.server.Start(s.serverAddr)
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.Error(err.Error())
+			s.Quit()
+		}
+	}()
+
+	s.logger.Info("Serving application at http://%s", s.serverAddr)
+
+	go func() {
+		if s.appoptions.OnStartup != nil {
+			s.appoptions.OnStartup(s.ctx)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) RunMainLoop() {
+	<-s.quit
+}
+
+func (s *Server) Quit() {
+	if s.appoptions.OnBeforeClose != nil {
+		go func() {
+			if !s.appoptions.OnBeforeClose(s.ctx) {
+				s.quitOnce.Do(func() { close(s.quit) })
+			}
+		}()
+		return
+	}
+	s.quitOnce.Do(func() { close(s.quit) })
+}
+
+func (s *Server) WindowClose() {
+	if err := s.server.Shutdown(context.Background()); err != nil {
+		s.logger.Error(err.Error())
+	}
+}
+
+func (s *Server) WindowReload() {
+	s.broadcast("reload")
+}
+
+func (s *Server) WindowReloadApp() {
+	s.broadcast("reloadapp")
+}
+
+func (s *Server) Notify(name string, data ...interface{}) {
+	s.notify(name, data...)
+}
+
+func (s *Server) BrowserOpenURL(url string) {
+	s.logger.Warning("BrowserOpenURL is not supported when running as a server: %s", url)
+}
+
+// Window, dialog and menu methods have no meaning without a window, so they are no-ops here
+
+func (s *Server) ExecJS(_ string)                           {}
+func (s *Server) Hide()                                     {}
+func (s *Server) Show()                                     {}
+func (s *Server) WindowSetTitle(_ string)                   {}
+func (s *Server) WindowShow()                               {}
+func (s *Server) WindowHide()                               {}
+func (s *Server) WindowCenter()                             {}
+func (s *Server) WindowToggleMaximise()                     {}
+func (s *Server) WindowMaximise()                           {}
+func (s *Server) WindowUnmaximise()                         {}
+func (s *Server) WindowMinimise()                           {}
+func (s *Server) WindowUnminimise()                         {}
+func (s *Server) WindowSetAlwaysOnTop(_ bool)               {}
+func (s *Server) WindowSetPosition(_ int, _ int)            {}
+func (s *Server) WindowGetPosition() (int, int)             { return 0, 0 }
+func (s *Server) WindowSetSize(_ int, _ int)                {}
+func (s *Server) WindowGetSize() (int, int)                 { return 0, 0 }
+func (s *Server) WindowSetMinSize(_ int, _ int)             {}
+func (s *Server) WindowSetMaxSize(_ int, _ int)             {}
+func (s *Server) WindowFullscreen()                         {}
+func (s *Server) WindowUnfullscreen()                       {}
+func (s *Server) WindowSetBackgroundColour(_ *options.RGBA) {}
+func (s *Server) WindowSetSystemDefaultTheme()              {}
+func (s *Server) WindowSetLightTheme()                      {}
+func (s *Server) WindowSetDarkTheme()                       {}
+func (s *Server) WindowIsMaximised() bool                   { return false }
+func (s *Server) WindowIsMinimised() bool                   { return false }
+func (s *Server) WindowIsNormal() bool                      { return true }
+func (s *Server) WindowIsFullscreen() bool                  { return false }
+func (s *Server) ScreenGetAll() ([]Screen, error)           { return nil, errNotSupported }
+func (s *Server) MenuSetApplicationMenu(_ *menu.Menu)       {}
+func (s *Server) MenuUpdateApplicationMenu()                {}
+func (s *Server) OpenFileDialog(_ frontend.OpenDialogOptions) (string, error) {
+	return "", errNotSupported
+}
+func (s *Server) OpenMultipleFilesDialog(_ frontend.OpenDialogOptions) ([]string, error) {
+	return nil, errNotSupported
+}
+func (s *Server) OpenDirectoryDialog(_ frontend.OpenDialogOptions) (string, error) {
+	return "", errNotSupported
+}
+func (s *Server) SaveFileDialog(_ frontend.SaveDialogOptions) (string, error) {
+	return "", errNotSupported
+}
+func (s *Server) MessageDialog(_ frontend.MessageDialogOptions) (string, error) {
+	return "", errNotSupported
+}
+
+// checkOrigin is the websocket handshake. It only accepts connections from pages served by this
+// server or from one of the allowed origins, so other sites can't call the bound methods.
+func (s *Server) checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return fmt.Errorf("null origin")
+	}
+	config.Origin = origin
+	if s.originAllowed(origin, req.Host) {
+		return nil
+	}
+	s.logger.Warning(fmt.Sprintf("[Server] Rejected websocket connection from origin %s", origin))
+	return fmt.Errorf("origin %s not allowed", origin)
+}
+
+// originAllowed returns true if the origin is the served host or one of the allowed origins
+func (s *Server) originAllowed(origin *url.URL, host string) bool {
+	if strings.EqualFold(origin.Host, host) {
+		return true
+	}
+	for _, allowed := range s.allowedOrigins {
+		if strings.EqualFold(allowed, origin.Scheme+"://"+origin.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleIPCWebSocket(c echo.Context) error {
+	websocket.Server{Handshake: s.checkOrigin, Handler: func(c *websocket.Conn) {
+		s.logger.Debug(fmt.Sprintf("[Server] Websocket client %p connected", c))
+		s.socketMutex.Lock()
+		s.websocketClients[c] = &sync.Mutex{}
+		locker := s.websocketClients[c]
+		s.socketMutex.Unlock()
+
+		defer func() {
+			s.socketMutex.Lock()
+			delete(s.websocketClients, c)
+			s.socketMutex.Unlock()
+			s.logger.Debug(fmt.Sprintf("[Server] Websocket client %p disconnected", c))
+		}()
+
+		var msg string
+		defer c.Close()
+		for {
+			if err := websocket.Message.Receive(c, &msg); err != nil {
+				break
+			}
+
+			if msg == "drag" {
+				continue
+			}
+
+			if msg == "DomReady" {
+				if s.appoptions.OnDomReady != nil {
+					s.appoptions.OnDomReady(s.ctx)
+				}
+				continue
+			}
+
+			// Notify the other clients of "EventEmit"
+			if len(msg) > 2 && strings.HasPrefix(msg, "EE") {
+				s.broadcastExcludingSender("n"+msg[2:], c)
+			}
+
+			result, err := s.dispatcher.ProcessMessage(msg, s)
+			if err != nil {
+				s.logger.Error(err.Error())
+			}
+			if result != "" {
+				locker.Lock()
+				if err = websocket.Message.Send(c, result); err != nil {
+					locker.Unlock()
+					break
+				}
+				locker.Unlock()
+			}
+		}
+	}}.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+type EventNotify struct {
+	Name string        `json:"name"`
+	Data []interface{} `json:"data"`
+}
+
+func (s *Server) notify(name string, data ...interface{}) {
+	notification := EventNotify{
+		Name: name,
+		Data: data,
+	}
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	s.broadcast("n" + string(payload))
+}
+
+func (s *Server) broadcast(message string) {
+	s.broadcastExcludingSender(message, nil)
+}
+
+func (s *Server) broadcastExcludingSender(message string, sender *websocket.Conn) {
+	s.socketMutex.Lock()
+	defer s.socketMutex.Unlock()
+	for client, locker := range s.websocketClients {
+		if client == sender {
+			continue
+		}
+		go func(client *websocket.Conn, locker *sync.Mutex) {
+			locker.Lock()
+			defer locker.Unlock()
+			if err := websocket.Message.Send(client, message); err != nil {
+				s.logger.Error(err.Error())
+			}
+		}(client, locker)
+	}
+}
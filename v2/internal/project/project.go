@@ -21,6 +21,10 @@ type Project struct {
 	BuildCommand   string `json:"frontend:build"`
 	InstallCommand string `json:"frontend:install"`
 
+	// Frontend build commands for specific platforms, used instead of BuildCommand when building for them.
+	// Key: GOOS. EG: "windows": "npm run build:windows"
+	PlatformBuildCommands map[string]string `json:"frontend:build:platform,omitempty"`
+
 	// Commands used in `wails dev`
 	DevCommand        string `json:"frontend:dev"`
 	DevBuildCommand   string `json:"frontend:dev:build"`
@@ -62,9 +66,24 @@ type Project struct {
 	// Key: GOOS        - Executed at platform level before/after all builds of the specific platform
 	// Key: *           - Executed at platform level before/after all builds of a platform
 	// Key: [empty]     - Executed at global level before/after all builds of all platforms
+	// The commands can use the tokens ${platform}, ${arch}, ${mode}, ${outputType} and ${name}. Post build hooks also get ${bin}
 	PostBuildHooks map[string]string `json:"postBuildHooks"`
 	PreBuildHooks  map[string]string `json:"preBuildHooks"`
 
+	// Global build hooks are executed once per build, however many archs it is for. The pre build hook is
+	// executed before the GOOS/GOARCH build hooks and the post build hook after the */* build hooks
+	GlobalPreBuildHook  string `json:"globalPreBuildHook"`
+	GlobalPostBuildHook string `json:"globalPostBuildHook"`
+
+	// OnBuildFailed is executed when a build fails, whichever phase it failed in, to clean up what the pre build
+	// hooks acquired. ${phase} is replaced with the phase that failed, EG: frontend, compile or pre-compile hook
+	OnBuildFailed string `json:"onBuildFailed"`
+
+	// Compile hooks use the same keys as the build hooks but are executed immediately before/after
+	// compiling the application, after the bindings and frontend have been built
+	PostCompileHooks map[string]string `json:"postCompileHooks"`
+	PreCompileHooks  map[string]string `json:"preCompileHooks"`
+
 	// The application author
 	Author Author
 
@@ -115,6 +134,14 @@ func (p *Project) GetBuildDir() string {
 	return filepath.Join(p.Path, p.BuildDir)
 }
 
+// GetBuildCommand returns the frontend build command for the given platform, falling back to BuildCommand
+func (p *Project) GetBuildCommand(platform string) string {
+	if command := p.PlatformBuildCommands[platform]; command != "" {
+		return command
+	}
+	return p.BuildCommand
+}
+
 func (p *Project) GetDevBuildCommand() string {
 	if p.DevBuildCommand != "" {
 		return p.DevBuildCommand
@@ -127,3 +127,39 @@ func TestProject_GetBuildDir(t *testing.T) {
 		})
 	}
 }
+
+func TestProject_GetBuildCommand(t *testing.T) {
+	inputJSON := `{"frontend:build": "npm run build", "frontend:build:platform": {"windows": "npm run build:windows", "darwin": ""}}`
+	tests := []struct {
+		name     string
+		platform string
+		want     string
+	}{
+		{
+			name:     "Should use the platform's command",
+			platform: "windows",
+			want:     "npm run build:windows",
+		},
+		{
+			name:     "Should fall back to the build command for other platforms",
+			platform: "linux",
+			want:     "npm run build",
+		},
+		{
+			name:     "Should fall back to the build command for an empty platform command",
+			platform: "darwin",
+			want:     "npm run build",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proj, err := project.Parse([]byte(inputJSON))
+			if err != nil {
+				t.Fatalf("Error parsing project: %s", err)
+			}
+			if got := proj.GetBuildCommand(tt.platform); got != tt.want {
+				t.Errorf("GetBuildCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package shell
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"os/exec"
 )
@@ -70,6 +71,41 @@ func RunCommand(directory string, command string, args ...string) (string, strin
 	return stdo.String(), stde.String(), err
 }
 
+// RunCommandWithEnv will run the given command + args in the given directory.
+// The given environment variables, in the form "key=value", are added to the current environment.
+// Will return stdout, stderr and error
+func RunCommandWithEnv(directory string, env []string, command string, args ...string) (string, string, error) {
+	return RunCommandWithEnvContext(context.Background(), directory, env, command, args...)
+}
+
+// RunCommandWithEnvContext will run the given command + args in the given directory, with the given
+// environment variables added to the current environment like RunCommandWithEnv.
+// The command is killed if the context is done before it completes.
+// Will return stdout, stderr and error
+func RunCommandWithEnvContext(ctx context.Context, directory string, env []string, command string, args ...string) (string, string, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = directory
+	cmd.Env = append(os.Environ(), env...)
+	var stdo, stde bytes.Buffer
+	cmd.Stdout = &stdo
+	cmd.Stderr = &stde
+	err := cmd.Run()
+	return stdo.String(), stde.String(), err
+}
+
+// RunCommandWithContext will run the given command + args in the given directory.
+// The command is killed if the context is done before it completes.
+// Will return stdout, stderr and error
+func RunCommandWithContext(ctx context.Context, directory string, command string, args ...string) (string, string, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = directory
+	var stdo, stde bytes.Buffer
+	cmd.Stdout = &stdo
+	cmd.Stderr = &stde
+	err := cmd.Run()
+	return stdo.String(), stde.String(), err
+}
+
 // RunCommandVerbose will run the given command + args in the given directory
 // Will return an error if one occurs
 func RunCommandVerbose(directory string, command string, args ...string) error {
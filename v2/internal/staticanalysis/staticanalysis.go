@@ -3,14 +3,17 @@ package staticanalysis
 import (
 	"go/ast"
 	"golang.org/x/tools/go/packages"
+	"os"
 	"path/filepath"
 	"strings"
 )
 
 type EmbedDetails struct {
-	BaseDir   string
-	EmbedPath string
-	All       bool
+	BaseDir    string
+	EmbedPath  string
+	All        bool
+	IsFile     bool   // The embed pattern matches files rather than a directory
+	SourceFile string // The Go file holding the embed directive
 }
 
 func (e *EmbedDetails) GetFullPath() string {
@@ -37,6 +40,9 @@ func GetEmbedDetails(sourcePath string) ([]*EmbedDetails, error) {
 		for index, file := range pkg.Syntax {
 			baseDir := filepath.Dir(pkg.CompiledGoFiles[index])
 			embedPaths := GetEmbedDetailsForFile(file, baseDir)
+			for _, embedPath := range embedPaths {
+				embedPath.SourceFile = pkg.CompiledGoFiles[index]
+			}
 			if len(embedPaths) > 0 {
 				result = append(result, embedPaths...)
 			}
@@ -68,9 +74,24 @@ func GetEmbedDetailsForFile(file *ast.File, baseDir string) []*EmbedDetails {
 					EmbedPath: path,
 					All:       all,
 					BaseDir:   baseDir,
+					IsFile:    isFilePattern(baseDir, path),
 				})
 			}
 		}
 	}
 	return result
 }
+
+// isFilePattern determines if the given embed pattern refers to files rather than a directory.
+// Existing paths are checked on disk. Otherwise, glob patterns and paths with a file extension
+// are considered to be files.
+func isFilePattern(baseDir string, embedPath string) bool {
+	info, err := os.Stat(filepath.Join(baseDir, embedPath))
+	if err == nil {
+		return !info.IsDir()
+	}
+	if strings.ContainsAny(embedPath, "*?[") {
+		return true
+	}
+	return filepath.Ext(embedPath) != ""
+}
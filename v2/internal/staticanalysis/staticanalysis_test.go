@@ -1,8 +1,13 @@
 package staticanalysis
 
 import (
-	"github.com/stretchr/testify/require"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetEmbedDetails(t *testing.T) {
@@ -40,7 +45,49 @@ func TestGetEmbedDetails(t *testing.T) {
 			for index, g := range got {
 				require.Equal(t, tt.want[index].EmbedPath, g.EmbedPath)
 				require.Equal(t, tt.want[index].All, g.All)
+				require.Equal(t, "main.go", filepath.Base(g.SourceFile))
 			}
 		})
 	}
 }
+
+func TestGetEmbedDetailsForFile(t *testing.T) {
+	baseDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(baseDir, "frontend", "dist"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "version"), []byte("1.0.0"), 0644))
+
+	source := `package main
+
+import "embed"
+
+//go:embed all:frontend/dist
+var assets embed.FS
+
+//go:embed version
+var version string
+
+//go:embed config.json
+var config []byte
+
+//go:embed images/*.png
+var images embed.FS
+
+//go:embed data
+var data embed.FS
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", source, parser.ParseComments)
+	require.NoError(t, err)
+
+	want := map[string]bool{
+		"frontend/dist": false,
+		"version":       true,
+		"config.json":   true,
+		"images/*.png":  true,
+		"data":          false,
+	}
+	got := GetEmbedDetailsForFile(file, baseDir)
+	require.Equal(t, len(want), len(got))
+	for _, g := range got {
+		require.Equal(t, want[g.EmbedPath], g.IsFile, g.EmbedPath)
+	}
+}
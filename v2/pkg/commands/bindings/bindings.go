@@ -1,6 +1,7 @@
 package bindings
 
 import (
+	"context"
 	"fmt"
 	"github.com/samber/lo"
 	"github.com/wailsapp/wails/v2/internal/shell"
@@ -16,11 +17,21 @@ type Options struct {
 	Tags             []string
 	ProjectDirectory string
 	GoModTidy        bool
+	Compiler         string          // The go command to use. Defaults to "go"
+	OutputDirectory  string          // The directory to generate the wailsjs module in. Defaults to the project's wailsjsdir
+	SchemaFile       string          // If set, a JSON description of the bound methods and models is written to this file
+	GoEnv            []string        // Extra environment variables for the go commands, EG: GOPROXY=off
+	Context          context.Context // If set, the go commands and the generated program are killed when it is done
 }
 
 // GenerateBindings generates bindings for the Wails project in the given ProjectDirectory.
 // If no project directory is given then the current working directory is used.
+// If the Context is done before the bindings are generated, its error is returned.
 func GenerateBindings(options Options) (string, error) {
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	filename, _ := lo.Coalesce(options.Filename, "wailsbindings")
 	if runtime.GOOS == "windows" {
@@ -32,6 +43,7 @@ func GenerateBindings(options Options) (string, error) {
 	filename = filepath.Join(tempDir, filename)
 
 	workingDirectory, _ := lo.Coalesce(options.ProjectDirectory, lo.Must(os.Getwd()))
+	compiler, _ := lo.Coalesce(options.Compiler, "go")
 
 	var stdout, stderr string
 	var err error
@@ -41,13 +53,19 @@ func GenerateBindings(options Options) (string, error) {
 	tagString := buildtags.Stringify(genModuleTags)
 
 	if options.GoModTidy {
-		stdout, stderr, err = shell.RunCommand(workingDirectory, "go", "mod", "tidy")
+		stdout, stderr, err = shell.RunCommandWithEnvContext(ctx, workingDirectory, options.GoEnv, compiler, "mod", "tidy")
+		if ctx.Err() != nil {
+			return stdout, ctx.Err()
+		}
 		if err != nil {
 			return stdout, fmt.Errorf("%s\n%s\n%s", stdout, stderr, err)
 		}
 	}
 
-	stdout, stderr, err = shell.RunCommand(workingDirectory, "go", "build", "-tags", tagString, "-o", filename)
+	stdout, stderr, err = shell.RunCommandWithEnvContext(ctx, workingDirectory, options.GoEnv, compiler, "build", "-tags", tagString, "-o", filename)
+	if ctx.Err() != nil {
+		return stdout, ctx.Err()
+	}
 	if err != nil {
 		return stdout, fmt.Errorf("%s\n%s\n%s", stdout, stderr, err)
 	}
@@ -57,7 +75,22 @@ func GenerateBindings(options Options) (string, error) {
 		_ = os.Remove(filename)
 	}()
 
-	stdout, stderr, err = shell.RunCommand(workingDirectory, filename)
+	// The generator program is told where to write through its environment:
+	//   WAILS_JS_DIR          - the OutputDirectory, used instead of the project's wailsjsdir
+	//   WAILS_BINDINGS_SCHEMA - the SchemaFile
+	// They are only set for the generator, not for the go commands or this process.
+	var env []string
+	if options.OutputDirectory != "" {
+		env = append(env, "WAILS_JS_DIR="+options.OutputDirectory)
+	}
+	if options.SchemaFile != "" {
+		env = append(env, "WAILS_BINDINGS_SCHEMA="+options.SchemaFile)
+	}
+
+	stdout, stderr, err = shell.RunCommandWithEnvContext(ctx, workingDirectory, env, filename)
+	if ctx.Err() != nil {
+		return stdout, ctx.Err()
+	}
 	if err != nil {
 		return stdout, fmt.Errorf("%s\n%s\n%s", stdout, stderr, err)
 	}
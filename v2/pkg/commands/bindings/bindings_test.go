@@ -1,6 +1,7 @@
 package bindings
 
 import (
+	"context"
 	"github.com/matryer/is"
 	"github.com/wailsapp/wails/v2/pkg/templates"
 	"os"
@@ -122,3 +123,15 @@ func TestGenerateBindings(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateBindingsCancelled(t *testing.T) {
+	i := is.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GenerateBindings(Options{
+		ProjectDirectory: t.TempDir(),
+		Context:          ctx,
+	})
+	i.Equal(err, context.Canceled)
+}
@@ -0,0 +1,16 @@
+package build
+
+// addArtifact records a file or directory generated by the build in GeneratedArtifacts.
+// Only artifacts that remain after the build are recorded:
+//   - the Windows icon generated from appicon.png, when build/windows/icon.ico didn't exist
+//   - the packaged application: the darwin .app bundle, the Linux AppImage or .deb file
+//   - the SHA256SUMS file and its signature
+//   - the C header generated with a c-shared BuildMode
+//
+// The following are normally removed by the build, so are only recorded when KeepAssets is set:
+//   - the Windows .syso resource file in the project directory
+//   - the per-arch binaries lipo'd into a darwin universal binary. These are also kept by KeepUniversalSlices
+//   - the AppImage AppDir and the .deb package staging directory
+func (o *Options) addArtifact(path string) {
+	o.GeneratedArtifacts = append(o.GeneratedArtifacts, path)
+}
@@ -2,6 +2,7 @@ package build
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,7 +10,9 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/Masterminds/semver"
 	"github.com/wailsapp/wails/v2/internal/system"
 
 	"github.com/leaanthony/gosod"
@@ -33,6 +36,9 @@ type BaseBuilder struct {
 	filesToDelete slicer.StringSlicer
 	projectData   *project.Project
 	options       *Options
+
+	// lock guards the state shared between concurrent compiles of the same project
+	lock sync.Mutex
 }
 
 // NewBaseBuilder creates a new BaseBuilder
@@ -124,6 +130,12 @@ func commandPrettifier(args []string) string {
 
 func (b *BaseBuilder) OutputFilename(options *Options) string {
 	outputFile := options.OutputFile
+	if outputFile == "" && options.OutputNameTemplate != "" {
+		outputFile = expandOutputNameTemplate(options, b.projectData)
+		if options.Platform == "windows" && !strings.HasSuffix(outputFile, ".exe") {
+			outputFile += ".exe"
+		}
+	}
 	if outputFile == "" {
 		target := strings.TrimSuffix(b.projectData.OutputFilename, ".exe")
 		if b.projectData.OutputType != "desktop" {
@@ -132,7 +144,7 @@ func (b *BaseBuilder) OutputFilename(options *Options) string {
 		// If we aren't using the standard compiler, add it to the filename
 		if options.Compiler != "go" {
 			// Parse the `go version` output. EG: `go version go1.16 windows/amd64`
-			stdout, _, err := shell.RunCommand(".", options.Compiler, "version")
+			stdout, err := goVersion(options)
 			if err != nil {
 				return ""
 			}
@@ -141,130 +153,54 @@ func (b *BaseBuilder) OutputFilename(options *Options) string {
 				target += "-" + versionSplit[2]
 			}
 		}
-		switch b.options.Platform {
+		switch options.Platform {
 		case "windows":
 			outputFile = target + ".exe"
-		case "darwin", "linux":
-			if b.options.Arch == "" {
-				b.options.Arch = runtime.GOARCH
+		case "darwin", "linux", "freebsd":
+			if options.Arch == "" {
+				options.Arch = runtime.GOARCH
 			}
-			outputFile = fmt.Sprintf("%s-%s-%s", target, b.options.Platform, b.options.Arch)
+			outputFile = fmt.Sprintf("%s-%s-%s", target, options.Platform, options.Arch)
 		}
 
 	}
+	if options.isSharedLibrary() {
+		return sharedLibraryFilename(outputFile, options.Platform)
+	}
 	return outputFile
 }
 
 // CompileProject compiles the project
 func (b *BaseBuilder) CompileProject(options *Options) error {
 
+	var err error
 	// Check if the runtime wrapper exists
-	err := generateRuntimeWrapper(options)
-	if err != nil {
-		return err
-	}
-
-	verbose := options.Verbosity == VERBOSE
-	// Run go mod tidy first
-	if !options.SkipModTidy {
-		cmd := exec.Command(options.Compiler, "mod", "tidy")
-		cmd.Stderr = os.Stderr
-		if verbose {
-			println("")
-			cmd.Stdout = os.Stdout
-		}
-		err = cmd.Run()
+	if !options.DryRun {
+		b.lock.Lock()
+		err = generateRuntimeWrapper(options)
+		b.lock.Unlock()
 		if err != nil {
 			return err
 		}
 	}
 
-	commands := slicer.String()
-
-	compiler := options.Compiler
-	if options.Obfuscated {
-		if !shell.CommandExists("garble") {
-			return fmt.Errorf("the 'garble' command was not found. Please install it with `go install mvdan.cc/garble@latest`")
-		} else {
-			compiler = "garble"
-			if options.GarbleArgs != "" {
-				commands.AddSlice(strings.Split(options.GarbleArgs, " "))
-			}
-			options.UserTags = append(options.UserTags, "obfuscated")
-		}
-	}
-
-	// Default go build command
-	commands.Add("build")
-
-	// Add better debugging flags
-	if options.Mode == Dev || options.Mode == Debug {
-		commands.Add("-gcflags")
-		commands.Add("all=-N -l")
-	}
-
-	if options.ForceBuild {
-		commands.Add("-a")
-	}
-
-	if options.TrimPath {
-		commands.Add("-trimpath")
-	}
-
-	if options.RaceDetector {
-		commands.Add("-race")
-	}
-
-	var tags slicer.StringSlicer
-	tags.Add(options.OutputType)
-	tags.AddSlice(options.UserTags)
-
-	// Add webview2 strategy if we have it
-	if options.WebView2Strategy != "" {
-		tags.Add(options.WebView2Strategy)
-	}
-
-	if options.Mode == Production || options.Mode == Debug {
-		tags.Add("production")
-	}
-	// This mode allows you to debug a production build (not dev build)
-	if options.Mode == Debug {
-		tags.Add("debug")
-	}
-
-	if options.Obfuscated {
-		tags.Add("obfuscated")
-	}
-
-	tags.Deduplicate()
-
-	// Add the output type build tag
-	commands.Add("-tags")
-	commands.Add(tags.Join(","))
-
-	// LDFlags
-	ldflags := slicer.String()
-	if options.LDFlags != "" {
-		ldflags.Add(options.LDFlags)
-	}
-
-	if options.Mode == Production {
-		ldflags.Add("-w", "-s")
-		if options.Platform == "windows" && !options.WindowsConsole {
-			ldflags.Add("-H windowsgui")
+	verbose := options.verbosity() == VERBOSE
+	// Run go mod tidy first
+	if options.modTidyMode() != ModTidySkip && !options.DryRun {
+		err = runModTidy(options)
+		if err != nil {
+			return err
 		}
 	}
 
-	ldflags.Deduplicate()
-
-	if ldflags.Length() > 0 {
-		commands.Add("-ldflags")
-		commands.Add(ldflags.Join(" "))
+	// Obfuscated builds are compiled with garble, so it must be installed
+	if options.Obfuscated && !shell.CommandExists("garble") {
+		return fmt.Errorf("the 'garble' command was not found. Please install it with `go install mvdan.cc/garble@latest`")
 	}
 
 	// Get application build directory
 	appDir := options.BinDirectory
-	if options.CleanBinDirectory {
+	if options.CleanBinDirectory && !options.DryRun {
 		err = cleanBinDirectory(options)
 		if err != nil {
 			return err
@@ -274,17 +210,23 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 	// Set up output filename
 	outputFile := b.OutputFilename(options)
 	compiledBinary := filepath.Join(appDir, outputFile)
-	commands.Add("-o")
-	commands.Add(compiledBinary)
 
+	compiler, commands, err := compileCommand(options, compiledBinary)
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
 	b.projectData.OutputFilename = strings.TrimPrefix(compiledBinary, options.ProjectData.Path)
+	b.lock.Unlock()
 	options.CompiledBinary = compiledBinary
 
 	// Build the application
-	cmd := exec.Command(compiler, commands.AsSlice()...)
-	cmd.Stderr = os.Stderr
+	cmd := exec.CommandContext(options.buildContext(), compiler, commands...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 	if verbose {
-		println("  Build command:", compiler, commandPrettifier(commands.AsSlice()))
+		println("  Build command:", compiler, commandPrettifier(append([]string{}, commands...)))
 		cmd.Stdout = os.Stdout
 	}
 	// Set the directory
@@ -293,10 +235,7 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 	// Add CGO flags
 	// TODO: Remove this as we don't generate headers any more
 	// We use the project/build dir as a temporary place for our generated c headers
-	buildBaseDir, err := fs.RelativeToCwd("build")
-	if err != nil {
-		return err
-	}
+	buildBaseDir := b.projectData.GetBuildDir()
 
 	cmd.Env = os.Environ() // inherit env
 
@@ -307,7 +246,10 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 				if v != "" {
 					v += " "
 				}
-				v += "-mmacosx-version-min=10.13"
+				v += "-mmacosx-version-min=" + macMinVersion(options)
+			}
+			if options.Reproducible {
+				v = reproducibleCFlags(v, b.projectData.Path)
 			}
 			return v
 		})
@@ -317,6 +259,9 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 				v += " "
 			}
 			v += "-I" + buildBaseDir
+			if options.Reproducible {
+				v = reproducibleCFlags(v, b.projectData.Path)
+			}
 			return v
 		})
 
@@ -336,7 +281,7 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 				return err
 			}
 			addUTIFramework := majorVersion >= 11
-			// Set the minimum Mac SDK to 10.13
+			// Set the minimum Mac SDK, 10.13 by default
 			cmd.Env = upsertEnv(cmd.Env, "CGO_LDFLAGS", func(v string) string {
 				if v != "" {
 					v += " "
@@ -344,10 +289,13 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 				if addUTIFramework {
 					v += "-framework UniformTypeIdentifiers "
 				}
-				v += "-mmacosx-version-min=10.13"
+				v += "-mmacosx-version-min=" + macMinVersion(options)
 
 				return v
 			})
+			cmd.Env = upsertEnv(cmd.Env, "MACOSX_DEPLOYMENT_TARGET", func(v string) string {
+				return macMinVersion(options)
+			})
 		}
 	}
 
@@ -359,19 +307,81 @@ func (b *BaseBuilder) CompileProject(options *Options) error {
 		return options.Arch
 	})
 
+	if options.CC != "" {
+		cmd.Env = upsertEnv(cmd.Env, "CC", func(v string) string {
+			return cgoCompiler(options.CC, options.Arch)
+		})
+	}
+	if options.CXX != "" {
+		cmd.Env = upsertEnv(cmd.Env, "CXX", func(v string) string {
+			return cgoCompiler(options.CXX, options.Arch)
+		})
+	}
+
+	if options.CGOEnabled != nil {
+		cmd.Env = upsertEnv(cmd.Env, "CGO_ENABLED", func(v string) string {
+			if *options.CGOEnabled {
+				return "1"
+			}
+			return "0"
+		})
+	}
+
+	cmd.Env = applyGoEnv(options, cmd.Env)
+
+	if options.Obfuscated {
+		garblePackages, err := garbleEnv(options)
+		if err != nil {
+			return err
+		}
+		if garblePackages != "" {
+			cmd.Env = upsertEnv(cmd.Env, "GOGARBLE", func(v string) string {
+				return garblePackages
+			})
+		}
+	}
+
+	if options.AMD64Level != "" && options.Arch == "amd64" {
+		cmd.Env = upsertEnv(cmd.Env, "GOAMD64", func(v string) string {
+			return options.AMD64Level
+		})
+	}
+
+	if options.GOARM != "" && options.Arch == "arm" {
+		cmd.Env = upsertEnv(cmd.Env, "GOARM", func(v string) string {
+			return options.GOARM
+		})
+	}
+
 	if verbose {
-		println("  Environment:", strings.Join(cmd.Env, " "))
+		// Only show what Wails sets, as the inherited environment hides it
+		println("  Environment changes:")
+		for _, change := range envChanges(os.Environ(), cmd.Env) {
+			println("    " + change)
+		}
+	}
+
+	if options.DryRun {
+		logDryRun(options, cmd.Dir, compiler, commands)
+		return nil
 	}
 
 	// Run command
 	err = cmd.Run()
-	cmd.Stderr = os.Stderr
+
+	// Report warnings separately from the rest of the compiler output
+	warnings, output := splitCompilerOutput(stderr.String())
+	for _, warning := range warnings {
+		options.Logger.Println("Compiler warning: %s", warning)
+	}
+	if len(output) > 0 {
+		_, _ = fmt.Fprintln(os.Stderr, strings.Join(output, "\n"))
+	}
 
 	// Format error if we have one
 	if err != nil {
 		if options.Platform == "darwin" {
-			output, _ := cmd.CombinedOutput()
-			stdErr := string(output)
+			stdErr := stderr.String()
 			if strings.Contains(err.Error(), "ld: framework not found UniformTypeIdentifiers") ||
 				strings.Contains(stdErr, "ld: framework not found UniformTypeIdentifiers") {
 				println(`
@@ -382,18 +392,84 @@ Please reinstall by doing the following:
 `)
 			}
 		}
-		return err
+		return &CompileError{Err: err, Output: stderr.String()}
+	}
+
+	if options.FailOnWarnings {
+		if err := warningsError(warnings); err != nil {
+			return err
+		}
+	}
+
+	return compressBinary(options)
+}
+
+// warningsError returns a CompileError, with the warnings as its output, when there are any
+func warningsError(warnings []string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	return &CompileError{
+		Err:    fmt.Errorf("the compiler reported %d warning(s) and warnings are treated as errors", len(warnings)),
+		Output: strings.Join(warnings, "\n"),
 	}
+}
 
-	if !options.Compress {
+// compressBinary compresses the compiled binary using the selected CompressMethod
+func compressBinary(options *Options) error {
+	switch options.CompressMethod {
+	case "", CompressNone:
 		return nil
+	case CompressUPX:
+		return compressWithUPX(options)
+	case CompressSelfExtractingZstd:
+		return fmt.Errorf("compression method '%s' is not supported yet", options.CompressMethod)
+	default:
+		return fmt.Errorf("unknown compression method '%s'", options.CompressMethod)
+	}
+}
+
+// minimumUPXVersion is the oldest UPX release known to support the targets Wails builds for
+var minimumUPXVersion = semver.MustParse("3.96")
+
+// checkUPX ensures UPX is installed before we start building and warns if it is
+// unlikely to be able to compress the target
+func checkUPX(options *Options) error {
+	upxPath, err := exec.LookPath("upx")
+	if err != nil {
+		return fmt.Errorf("UPX not found on PATH; install from https://upx.github.io or disable -upx")
 	}
 
-	fmt.Printf("Compressing application: ")
+	stdout, _, err := shell.RunCommand(".", upxPath, "--version")
+	if err != nil {
+		return fmt.Errorf("unable to determine UPX version: %w", err)
+	}
+	// The first line of the output is in the form `upx 4.0.2`
+	firstLine := strings.SplitN(stdout, "\n", 2)[0]
+	versionString := strings.TrimSpace(strings.TrimPrefix(firstLine, "upx"))
+	version, err := semver.NewVersion(versionString)
+	if err != nil {
+		options.Logger.Println("Warning: unable to parse UPX version '%s'", firstLine)
+		return nil
+	}
+	if version.LessThan(minimumUPXVersion) {
+		options.Logger.Println("Warning: UPX %s is older than %s and may not support %s/%s binaries", version, minimumUPXVersion, options.Platform, options.Arch)
+	}
+	if options.Platform == "darwin" || (options.Platform == "windows" && options.Arch == "arm64") {
+		options.Logger.Println("Warning: UPX support for %s/%s binaries is limited and compression may fail", options.Platform, options.Arch)
+	}
+	return nil
+}
+
+func compressWithUPX(options *Options) error {
+	verbose := options.verbosity() == VERBOSE
+
+	outputLogger := options.Logger
+	outputLogger.Print("Compressing application: ")
 
 	// Do we have upx installed?
 	if !shell.CommandExists("upx") {
-		println("Warning: Cannot compress binary: upx not found")
+		outputLogger.Println("Warning: Cannot compress binary: upx not found")
 		return nil
 	}
 
@@ -412,7 +488,7 @@ Please reinstall by doing the following:
 	if err != nil {
 		return errors.Wrap(err, "Error during compression:")
 	}
-	println("Done.")
+	outputLogger.Println("Done.")
 	if verbose {
 		println(string(output))
 	}
@@ -420,10 +496,257 @@ Please reinstall by doing the following:
 	return nil
 }
 
+// compileCommand returns the compiler and the arguments used to compile the application to the given binary.
+// When obfuscating, the garble arguments come before `build` and the go build flags, including the ldflags,
+// come after it so that garble passes them through to the go toolchain.
+func compileCommand(options *Options, compiledBinary string) (string, []string, error) {
+	commands := slicer.String()
+
+	compiler := options.Compiler
+	if options.Obfuscated {
+		compiler = "garble"
+		if options.GarbleArgs != "" {
+			garbleArgs := strings.Split(options.GarbleArgs, " ")
+			for _, arg := range garbleArgs {
+				if arg == "-ldflags" || strings.HasPrefix(arg, "-ldflags=") {
+					return "", nil, fmt.Errorf("ldflags cannot be passed in the garble arguments as they would not reach the go toolchain. Please use the ldflags option instead")
+				}
+			}
+			commands.AddSlice(garbleArgs)
+		}
+	}
+
+	// Default go build command
+	commands.Add("build")
+
+	// Add better debugging flags
+	if options.Mode == Dev || options.Mode == Debug {
+		commands.Add("-gcflags")
+		commands.Add("all=-N -l")
+	}
+
+	if options.ForceBuild {
+		commands.Add("-a")
+	}
+
+	if options.TrimPath {
+		commands.Add("-trimpath")
+	}
+
+	if options.RaceDetector {
+		commands.Add("-race")
+	}
+
+	if options.CompileParallelism > 0 {
+		commands.Add("-p")
+		commands.Add(strconv.Itoa(options.CompileParallelism))
+	}
+
+	if options.BuildMode != "" {
+		commands.Add("-buildmode=" + options.BuildMode)
+	}
+
+	switch options.PGOProfile {
+	case "":
+	case PGOAuto:
+		commands.Add("-pgo=" + PGOAuto)
+	default:
+		commands.Add("-pgo=" + pgoProfile(options))
+	}
+
+	// Add the output type build tag
+	commands.Add("-tags")
+	commands.Add(buildTags(options))
+
+	// LDFlags
+	ldflags := resolveLDFlags(options)
+	if ldflags != "" {
+		commands.Add("-ldflags")
+		commands.Add(ldflags)
+	}
+
+	commands.Add("-o")
+	commands.Add(compiledBinary)
+
+	// Extra flags come last so they can't clobber the ones above
+	for _, flag := range options.ExtraGoFlags {
+		// Values given as separate arguments, EG: `-gcflags all=-l`, are not flags
+		if strings.HasPrefix(flag, "-") {
+			name := strings.SplitN(strings.TrimLeft(flag, "-"), "=", 2)[0]
+			if name == "buildmode" && options.BuildMode != "" {
+				return "", nil, fmt.Errorf("the go flag '%s' conflicts with the build mode '%s'", flag, options.BuildMode)
+			}
+			if name == "pgo" && options.PGOProfile != "" {
+				return "", nil, fmt.Errorf("the go flag '%s' conflicts with the PGO profile '%s'", flag, options.PGOProfile)
+			}
+			if option, reserved := reservedGoFlags[name]; reserved {
+				return "", nil, fmt.Errorf("the go flag '%s' is set by Wails and cannot be passed as an extra flag. Please use the %s option instead", flag, option)
+			}
+			if name == "gcflags" && (options.Mode == Dev || options.Mode == Debug) {
+				return "", nil, fmt.Errorf("the go flag '%s' is set by Wails in %s mode and cannot be passed as an extra flag", flag, options.Mode)
+			}
+		}
+		commands.Add(flag)
+	}
+
+	// The main package to build. Go builds the current directory, the project root, if none is given
+	if options.EntryPoint != "" {
+		commands.Add(entryPointPackage(options.EntryPoint))
+	}
+
+	return compiler, commands.AsSlice(), nil
+}
+
+// entryPointPackage returns the go build package argument of the given entry point directory.
+// Relative paths are given a leading ./ so that go doesn't treat them as import paths.
+func entryPointPackage(entryPoint string) string {
+	if filepath.IsAbs(entryPoint) {
+		return entryPoint
+	}
+	entryPoint = filepath.ToSlash(filepath.Clean(entryPoint))
+	if entryPoint == "." || entryPoint == ".." || strings.HasPrefix(entryPoint, "../") {
+		return entryPoint
+	}
+	return "./" + entryPoint
+}
+
+// reservedGoFlags maps the go build flags Wails always sets to the option that controls them
+var reservedGoFlags = map[string]string{
+	"o":       "output file",
+	"ldflags": "ldflags",
+	"tags":    "tags",
+}
+
+// buildTags returns the comma separated build tags to compile the application with
+func buildTags(options *Options) string {
+	var tags slicer.StringSlicer
+	tags.Add(options.OutputType)
+	tags.AddSlice(options.UserTags)
+
+	// Add webview2 strategy if we have it
+	if options.WebView2Strategy != "" {
+		tags.Add(options.WebView2Strategy)
+	}
+
+	if options.Mode == Production || options.Mode == Debug {
+		tags.Add("production")
+	}
+	// This mode allows you to debug a production build (not dev build)
+	if options.Mode == Debug {
+		tags.Add("debug")
+	}
+
+	// The obfuscated tag is added here, not to the options' tags, so they are left unchanged across builds
+	if options.Obfuscated {
+		tags.Add("obfuscated")
+	}
+
+	tags.Deduplicate()
+
+	return tags.Join(",")
+}
+
+// resolveLDFlags returns the linker flags to use for the given options
+func resolveLDFlags(options *Options) string {
+	ldflags := slicer.String()
+	if options.LDFlags != "" {
+		ldflags.Add(options.LDFlags)
+	}
+	for _, flags := range platformLDFlags(options) {
+		ldflags.Add(flags)
+	}
+	for _, flag := range linkVarsLDFlags(options) {
+		ldflags.Add(flag)
+	}
+	if options.buildInfo != "" {
+		ldflags.Add(options.buildInfo)
+	}
+	if options.Reproducible {
+		ldflags.Add("-buildid=")
+	}
+
+	// Stripping symbols is independent of TrimPath, which only removes file system paths
+	if options.StripSymbols && options.Mode != Debug {
+		ldflags.Add("-w", "-s")
+	}
+
+	if options.Mode == Production {
+		ldflags.Add("-w", "-s")
+	}
+
+	// GUI applications don't open a console window unless asked to or the user chose another -H.
+	// Shared libraries are loaded by their host application, which owns the console, and servers
+	// have no window so are always console applications
+	if options.Platform == "windows" && !options.WindowsConsole && !options.isSharedLibrary() && options.OutputType != "server" && !hasHeaderTypeFlag(ldflags.Join(" ")) {
+		ldflags.Add("-H windowsgui")
+	}
+
+	ldflags.Deduplicate()
+
+	return ldflags.Join(" ")
+}
+
+// hasHeaderTypeFlag returns true if the given linker flags set the executable header type, EG: -H windowsgui
+func hasHeaderTypeFlag(ldflags string) bool {
+	for _, flag := range strings.Fields(ldflags) {
+		if !strings.HasPrefix(flag, "-") {
+			continue
+		}
+		name := strings.TrimLeft(flag, "-")
+		if name == "H" || strings.HasPrefix(name, "H=") {
+			return true
+		}
+	}
+	return false
+}
+
+// platformLDFlags returns the PlatformLDFlags for the target, in the order they are added after the base
+// LDFlags: those of the platform (GOOS or GOOS/*) and then those of the platform and arch (GOOS/GOARCH).
+// As the linker uses the last value of a flag, the most specific flags take precedence.
+func platformLDFlags(options *Options) []string {
+	var result []string
+	for _, key := range []string{options.Platform, options.Platform + "/*", options.Platform + "/" + options.Arch} {
+		if flags := options.PlatformLDFlags[key]; flags != "" {
+			result = append(result, flags)
+		}
+	}
+	return result
+}
+
+// logDryRun reports a command that would have been run in dry run mode.
+// In verbose mode each command is written as a single JSON object so that it can be parsed.
+func logDryRun(options *Options, dir string, command string, args []string) {
+	if options.verbosity() == VERBOSE {
+		data, _ := json.Marshal(map[string]interface{}{
+			"dir":     dir,
+			"command": command,
+			"args":    args,
+		})
+		options.Logger.Println("dryrun: %s", string(data))
+		return
+	}
+	options.Logger.Println("  Dry run: %s %s", command, commandPrettifier(append([]string{}, args...)))
+}
+
+// runModTidy runs `go mod tidy` or, in the verify ModTidyMode, checks the module is already tidy
+func runModTidy(options *Options) error {
+	if options.modTidyMode() == ModTidyVerify {
+		return verifyModTidy(options)
+	}
+	cmd := exec.CommandContext(options.buildContext(), options.Compiler, "mod", "tidy")
+	cmd.Dir = options.ProjectData.Path
+	cmd.Stderr = os.Stderr
+	if options.verbosity() == VERBOSE {
+		println("")
+		cmd.Stdout = os.Stdout
+	}
+	return cmd.Run()
+}
+
 func generateRuntimeWrapper(options *Options) error {
 
 	if options.WailsJSDir == "" {
-		cwd, err := os.Getwd()
+		cwd, err := options.workingDir()
 		if err != nil {
 			return err
 		}
@@ -496,7 +819,7 @@ func (b *BaseBuilder) NpmInstallUsingCommand(sourceDir string, installCommand st
 
 	// Split up the InstallCommand and execute it
 	cmd := strings.Split(installCommand, " ")
-	stdout, stderr, err := shell.RunCommand(sourceDir, cmd[0], cmd[1:]...)
+	stdout, stderr, err := shell.RunCommandWithContext(b.options.buildContext(), sourceDir, cmd[0], cmd[1:]...)
 	if verbose || err != nil {
 		for _, l := range strings.Split(stdout, "\n") {
 			fmt.Printf("    %s\n", l)
@@ -545,18 +868,39 @@ func (b *BaseBuilder) NpmRunWithEnvironment(projectDir, buildTarget string, verb
 // BuildFrontend executes the `npm build` command for the frontend directory
 func (b *BaseBuilder) BuildFrontend(outputLogger *clilogger.CLILogger) error {
 
-	verbose := b.options.Verbosity == VERBOSE
+	verbose := b.options.verbosity() == VERBOSE
 
 	frontendDir := b.projectData.GetFrontendDir()
 	if !fs.DirExists(frontendDir) {
 		return fmt.Errorf("frontend directory '%s' does not exist", frontendDir)
 	}
 
-	// Check there is an 'InstallCommand' provided in wails.json
 	installCommand := b.projectData.InstallCommand
+	buildCommand := b.projectData.GetBuildCommand(b.options.Platform)
 	if b.projectData.OutputType == "dev" {
 		installCommand = b.projectData.GetDevInstallerCommand()
+		buildCommand = b.projectData.GetDevBuildCommand()
+	}
+
+	// npm commands are run with the project's package manager
+	if strings.HasPrefix(installCommand, "npm ") || strings.HasPrefix(buildCommand, "npm ") {
+		packageManager, err := frontendPackageManager(b.options, frontendDir)
+		if err != nil {
+			return err
+		}
+		installCommand = packageManagerCommand(installCommand, packageManager)
+		buildCommand = packageManagerCommand(buildCommand, packageManager)
+	}
+
+	if b.options.Offline && installCommand != "" {
+		var err error
+		installCommand, err = offlineInstallCommand(installCommand)
+		if err != nil {
+			return err
+		}
 	}
+
+	// Check there is an 'InstallCommand' provided in wails.json
 	if installCommand == "" {
 		// No - don't install
 		outputLogger.Println("  - No Install command. Skipping.")
@@ -574,10 +918,6 @@ func (b *BaseBuilder) BuildFrontend(outputLogger *clilogger.CLILogger) error {
 	}
 
 	// Check if there is a build command
-	buildCommand := b.projectData.BuildCommand
-	if b.projectData.OutputType == "dev" {
-		buildCommand = b.projectData.GetDevBuildCommand()
-	}
 	if buildCommand == "" {
 		outputLogger.Println("  - No Build command. Skipping.")
 		// No - ignore
@@ -590,7 +930,7 @@ func (b *BaseBuilder) BuildFrontend(outputLogger *clilogger.CLILogger) error {
 		outputLogger.Println("")
 		outputLogger.Println("  Build command: '" + buildCommand + "'")
 	}
-	stdout, stderr, err := shell.RunCommand(frontendDir, cmd[0], cmd[1:]...)
+	stdout, stderr, err := shell.RunCommandWithContext(b.options.buildContext(), frontendDir, cmd[0], cmd[1:]...)
 	if verbose || err != nil {
 		for _, l := range strings.Split(stdout, "\n") {
 			fmt.Printf("    %s\n", l)
@@ -607,6 +947,28 @@ func (b *BaseBuilder) BuildFrontend(outputLogger *clilogger.CLILogger) error {
 	return nil
 }
 
+// envChanges returns the variables of env that are not in base or have a different value, in the
+// form KEY=value. Changed values are followed by the value in base, EG: `GOOS=darwin (was linux)`.
+func envChanges(base []string, env []string) []string {
+	inherited := map[string]string{}
+	for _, variable := range base {
+		key, value, _ := strings.Cut(variable, "=")
+		inherited[key] = value
+	}
+	var result []string
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		previous, found := inherited[key]
+		switch {
+		case !found:
+			result = append(result, variable)
+		case previous != value:
+			result = append(result, fmt.Sprintf("%s (was %s)", variable, previous))
+		}
+	}
+	return result
+}
+
 func upsertEnv(env []string, key string, update func(v string) string) []string {
 	newEnv := make([]string, len(env), len(env)+1)
 	found := false
@@ -1,6 +1,24 @@
 package build
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/project"
+	"github.com/wailsapp/wails/v2/pkg/clilogger"
+)
 
 func TestUpdateEnv(t *testing.T) {
 
@@ -66,3 +84,553 @@ func Test_commandPrettifier(t *testing.T) {
 		})
 	}
 }
+
+func Test_compileCommandObfuscatedLDFlags(t *testing.T) {
+	options := &Options{
+		Compiler:    "go",
+		OutputType:  "desktop",
+		Mode:        Production,
+		Platform:    "linux",
+		Obfuscated:  true,
+		GarbleArgs:  "-literals -tiny",
+		LDFlags:     "-X main.version=1.2.3",
+		ProjectData: &project.Project{},
+	}
+	compiler, args, err := compileCommand(options, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compiler != "garble" {
+		t.Errorf("expected compiler garble, got %s", compiler)
+	}
+	if !reflect.DeepEqual(args[:3], []string{"-literals", "-tiny", "build"}) {
+		t.Errorf("expected garble arguments before build, got %q", args)
+	}
+	ldflagsIndex := lo.IndexOf(args, "-ldflags")
+	if ldflagsIndex < 3 || !strings.Contains(args[ldflagsIndex+1], "-X main.version=1.2.3") {
+		t.Errorf("expected ldflags with version after build, got %q", args)
+	}
+
+	options.GarbleArgs = "-literals -ldflags=-X=main.version=1.2.3"
+	if _, _, err := compileCommand(options, "app"); err == nil {
+		t.Errorf("expected an error when ldflags are given in the garble arguments")
+	}
+}
+
+func Test_garblePackages(t *testing.T) {
+	modules := []string{
+		"changeme",
+		"github.com/labstack/echo/v4",
+		"github.com/wailsapp/wails/v2",
+		"github.com/json-iterator/go",
+	}
+	tests := []struct {
+		name    string
+		exclude []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "modules are excluded",
+			exclude: []string{"github.com/json-iterator/go", "github.com/labstack/echo/v4"},
+			want:    "changeme,github.com/wailsapp/wails/v2",
+		},
+		{
+			name:    "prefixes exclude every module below them",
+			exclude: []string{"github.com/labstack", "github.com/wailsapp"},
+			want:    "changeme,github.com/json-iterator/go",
+		},
+		{
+			name:    "packages inside an obfuscated module can't be excluded",
+			exclude: []string{"github.com/wailsapp/wails/v2/pkg/runtime"},
+			wantErr: true,
+		},
+		{
+			name:    "packages inside an excluded module are allowed",
+			exclude: []string{"github.com/wailsapp/wails/v2", "github.com/wailsapp/wails/v2/pkg/runtime"},
+			want:    "changeme,github.com/labstack/echo/v4,github.com/json-iterator/go",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := garblePackages(modules, tt.exclude)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("garblePackages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("garblePackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_compileCommandExtraGoFlags(t *testing.T) {
+	options := &Options{
+		Compiler:     "go",
+		OutputType:   "desktop",
+		Mode:         Production,
+		Platform:     "linux",
+		ExtraGoFlags: []string{"-gcflags=all=-l", "-buildmode", "pie"},
+		ProjectData:  &project.Project{},
+	}
+	_, args, err := compileCommand(options, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args[len(args)-5:], []string{"-o", "app", "-gcflags=all=-l", "-buildmode", "pie"}) {
+		t.Errorf("expected extra flags after the Wails flags, got %q", args)
+	}
+
+	for _, flags := range [][]string{{"-o", "other"}, {"--ldflags=-s"}, {"-tags", "foo"}} {
+		options.ExtraGoFlags = flags
+		if _, _, err := compileCommand(options, "app"); err == nil {
+			t.Errorf("expected an error for the extra flags %q", flags)
+		}
+	}
+
+	options.Mode = Dev
+	options.ExtraGoFlags = []string{"-gcflags=all=-l"}
+	if _, _, err := compileCommand(options, "app"); err == nil {
+		t.Errorf("expected an error for gcflags in dev mode")
+	}
+}
+
+func Test_sharedLibraryFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		platform string
+		want     string
+	}{
+		{filename: "app.exe", platform: "windows", want: "app.dll"},
+		{filename: "app.dll", platform: "windows", want: "app.dll"},
+		{filename: "app-darwin-arm64", platform: "darwin", want: "app-darwin-arm64.dylib"},
+		{filename: "app-linux-amd64", platform: "linux", want: "app-linux-amd64.so"},
+		{filename: "libapp.so", platform: "freebsd", want: "libapp.so"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := sharedLibraryFilename(tt.filename, tt.platform); got != tt.want {
+				t.Errorf("sharedLibraryFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_applyGoEnv(t *testing.T) {
+	env := []string{"HOME=/home/user", "GOFLAGS=-trimpath -mod=readonly"}
+	if got := applyGoEnv(&Options{}, env); !reflect.DeepEqual(got, env) {
+		t.Errorf("applyGoEnv() = %v, want the environment unchanged", got)
+	}
+
+	want := []string{"HOME=/home/user", "GOFLAGS=-trimpath -mod=mod", "GOPROXY=off"}
+	if got := applyGoEnv(&Options{Offline: true}, env); !reflect.DeepEqual(got, want) {
+		t.Errorf("applyGoEnv() = %v, want %v", got, want)
+	}
+
+	want = []string{"HOME=/home/user", "GOFLAGS=-trimpath -mod=vendor", "GOPROXY=off"}
+	if got := applyGoEnv(&Options{Offline: true, UseVendor: true}, env); !reflect.DeepEqual(got, want) {
+		t.Errorf("applyGoEnv() = %v, want %v", got, want)
+	}
+}
+
+func Test_goEnvChanges(t *testing.T) {
+	t.Setenv("GOFLAGS", "-trimpath")
+	t.Setenv("GOPROXY", "https://proxy.golang.org")
+	if got := goEnvChanges(&Options{}); len(got) != 0 {
+		t.Errorf("goEnvChanges() = %v, want no changes", got)
+	}
+
+	got := goEnvChanges(&Options{Offline: true})
+	sort.Strings(got)
+	want := []string{"GOFLAGS=-trimpath -mod=mod", "GOPROXY=off"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("goEnvChanges() = %v, want %v", got, want)
+	}
+}
+
+func Test_buildInfoLDFlags(t *testing.T) {
+	buildTime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	options := &Options{
+		ProjectData:        &project.Project{Path: t.TempDir()},
+		BuildInfoVarPrefix: "example.com/app/version",
+	}
+	want := "-X example.com/app/version.commit=unknown -X example.com/app/version.dirty=unknown -X example.com/app/version.buildTime=2024-03-01T11:30:00Z"
+	if got := buildInfoLDFlags(options, buildTime); got != want {
+		t.Errorf("buildInfoLDFlags() = %v, want %v", got, want)
+	}
+}
+
+func Test_splitCompilerOutput(t *testing.T) {
+	stderr := `# runtime/cgo
+ld: warning: object file was built for newer macOS version (14.0) than being linked (10.13)
+# example.com/app
+main.c:10:2: warning: unused variable 'x' [-Wunused-variable]
+./main.go:5:2: undefined: foo
+`
+	warnings, output := splitCompilerOutput(stderr)
+	wantWarnings := []string{
+		"ld: warning: object file was built for newer macOS version (14.0) than being linked (10.13)",
+		"main.c:10:2: warning: unused variable 'x' [-Wunused-variable]",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("splitCompilerOutput() warnings = %v, want %v", warnings, wantWarnings)
+	}
+	wantOutput := []string{"# example.com/app", "./main.go:5:2: undefined: foo"}
+	if !reflect.DeepEqual(output, wantOutput) {
+		t.Errorf("splitCompilerOutput() output = %v, want %v", output, wantOutput)
+	}
+}
+
+func Test_warningsError(t *testing.T) {
+	if err := warningsError(nil); err != nil {
+		t.Errorf("warningsError(nil) = %v, want nil", err)
+	}
+
+	warnings := []string{"ld: warning: one", "main.c:10:2: warning: two"}
+	err := warningsError(warnings)
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("warningsError() = %#v, want a CompileError", err)
+	}
+	if want := "ld: warning: one\nmain.c:10:2: warning: two"; compileErr.Output != want {
+		t.Errorf("CompileError.Output = %q, want %q", compileErr.Output, want)
+	}
+	if !strings.Contains(err.Error(), "2 warning(s)") {
+		t.Errorf("warningsError() = %q, want the number of warnings", err)
+	}
+}
+
+func Test_entryPointPackage(t *testing.T) {
+	tests := []struct {
+		entryPoint string
+		want       string
+	}{
+		{entryPoint: "cmd/helper", want: "./cmd/helper"},
+		{entryPoint: "./cmd/helper/", want: "./cmd/helper"},
+		{entryPoint: ".", want: "."},
+		{entryPoint: "../shared/cli", want: "../shared/cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.entryPoint, func(t *testing.T) {
+			if got := entryPointPackage(tt.entryPoint); got != tt.want {
+				t.Errorf("entryPointPackage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_envChanges(t *testing.T) {
+	base := []string{"HOME=/home/user", "GOOS=linux", "CGO_ENABLED=0"}
+	env := []string{"HOME=/home/user", "GOOS=darwin", "CGO_ENABLED=0", "GOARCH=arm64"}
+	want := []string{"GOOS=darwin (was linux)", "GOARCH=arm64"}
+	if got := envChanges(base, env); !reflect.DeepEqual(got, want) {
+		t.Errorf("envChanges() = %v, want %v", got, want)
+	}
+}
+
+func Test_cgoCompiler(t *testing.T) {
+	options := &Options{Arch: "universal"}
+	var got []string
+	for _, arch := range compiledArchs(options) {
+		got = append(got, cgoCompiler("${arch}-apple-darwin-clang", arch))
+	}
+	want := []string{"amd64-apple-darwin-clang", "arm64-apple-darwin-clang"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cgoCompiler() = %v, want %v", got, want)
+	}
+}
+
+func Test_isStaleCacheError(t *testing.T) {
+	stale := &CompileError{Err: errors.New("exit status 1"), Output: "runtime: compiled Go object is stale"}
+	if !isStaleCacheError(fmt.Errorf("compiling amd64: %w", stale)) {
+		t.Errorf("isStaleCacheError() = false for a stale cache error")
+	}
+	other := &CompileError{Err: errors.New("exit status 1"), Output: "./main.go:5:2: undefined: foo"}
+	if isStaleCacheError(other) {
+		t.Errorf("isStaleCacheError() = true for a compile error")
+	}
+	if isStaleCacheError(errors.New("compiled Go object is stale")) {
+		t.Errorf("isStaleCacheError() = true for an error that isn't from the compiler")
+	}
+}
+
+func Test_compileCommandPGO(t *testing.T) {
+	options := &Options{
+		Compiler:    "go",
+		OutputType:  "desktop",
+		Mode:        Production,
+		Platform:    "linux",
+		PGOProfile:  "profiles/cpu.pprof",
+		ProjectData: &project.Project{Path: "/project"},
+	}
+	_, args, err := compileCommand(options, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lo.Contains(args, "-pgo="+filepath.Join("/project", "profiles", "cpu.pprof")) {
+		t.Errorf("expected the PGO profile relative to the project, got %q", args)
+	}
+
+	options.PGOProfile = PGOAuto
+	options.ExtraGoFlags = []string{"-pgo=off"}
+	if _, _, err := compileCommand(options, "app"); err == nil {
+		t.Errorf("expected an error for a -pgo extra flag")
+	}
+}
+
+func Test_compileCommandPlatformLDFlags(t *testing.T) {
+	for _, tt := range []struct {
+		platform string
+		arch     string
+		want     string
+	}{
+		{platform: "windows", arch: "amd64", want: "-X main.version=1.2.3 -H windowsgui -X main.arch=windows-amd64"},
+		{platform: "windows", arch: "arm64", want: "-X main.version=1.2.3 -H windowsgui"},
+		{platform: "linux", arch: "amd64", want: "-X main.version=1.2.3"},
+	} {
+		options := &Options{
+			Compiler:   "go",
+			OutputType: "desktop",
+			Mode:       Dev,
+			Platform:   tt.platform,
+			Arch:       tt.arch,
+			LDFlags:    "-X main.version=1.2.3",
+			PlatformLDFlags: map[string]string{
+				"windows":       "-H windowsgui",
+				"windows/amd64": "-X main.arch=windows-amd64",
+				"darwin/*":      "-X main.arch=darwin",
+			},
+			WindowsConsole: true,
+			ProjectData:    &project.Project{},
+		}
+		_, args, err := compileCommand(options, "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ldflagsIndex := lo.IndexOf(args, "-ldflags")
+		if ldflagsIndex == -1 || args[ldflagsIndex+1] != tt.want {
+			t.Errorf("%s/%s: expected ldflags %q, got %q", tt.platform, tt.arch, tt.want, args)
+		}
+	}
+}
+
+func Test_compileCommandWindowsGUI(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           Mode
+		windowsConsole bool
+		outputType     string
+		ldflags        string
+		want           bool
+	}{
+		{name: "production", mode: Production, want: true},
+		{name: "debug", mode: Debug, want: true},
+		{name: "console kept", mode: Production, windowsConsole: true, want: false},
+		{name: "user header type", mode: Production, ldflags: "-H=windows", want: false},
+		{name: "server", mode: Production, outputType: "server", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputType, _ := lo.Coalesce(tt.outputType, "desktop")
+			options := &Options{
+				Compiler:       "go",
+				OutputType:     outputType,
+				Mode:           tt.mode,
+				Platform:       "windows",
+				Arch:           "amd64",
+				LDFlags:        tt.ldflags,
+				WindowsConsole: tt.windowsConsole,
+				ProjectData:    &project.Project{},
+			}
+			_, args, err := compileCommand(options, "app.exe")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ldflags := ""
+			if ldflagsIndex := lo.IndexOf(args, "-ldflags"); ldflagsIndex != -1 {
+				ldflags = args[ldflagsIndex+1]
+			}
+			if got := strings.Contains(ldflags, "-H windowsgui"); got != tt.want {
+				t.Errorf("-H windowsgui in ldflags %q = %v, want %v", ldflags, got, tt.want)
+			}
+			if options.WindowsConsole != tt.windowsConsole {
+				t.Errorf("compileCommand() changed WindowsConsole to %v", options.WindowsConsole)
+			}
+		})
+	}
+}
+
+func Test_compileCommandLinkVars(t *testing.T) {
+	options := &Options{
+		Compiler:   "go",
+		OutputType: "desktop",
+		Mode:       Dev,
+		Platform:   "linux",
+		LDFlags:    "-X main.version=0.0.0",
+		LinkVars: map[string]string{
+			"main.version":         "1.2.3",
+			"main.title":           "My App",
+			"example.com/app.note": `say "hi"`,
+		},
+		ProjectData: &project.Project{},
+	}
+	_, args, err := compileCommand(options, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ldflagsIndex := lo.IndexOf(args, "-ldflags")
+	if ldflagsIndex == -1 {
+		t.Fatalf("expected ldflags, got %q", args)
+	}
+	want := `-X main.version=0.0.0 -X 'example.com/app.note=say "hi"' -X 'main.title=My App' -X main.version=1.2.3`
+	if got := args[ldflagsIndex+1]; got != want {
+		t.Errorf("expected ldflags %q, got %q", want, got)
+	}
+
+	if _, err := linkVarFlag("version", "1.2.3"); err == nil {
+		t.Errorf("expected an error for an unqualified variable")
+	}
+	if _, err := linkVarFlag("main.note", `it's "both" quotes`); err == nil {
+		t.Errorf("expected an error for a value with spaces and both quotes")
+	}
+}
+
+func Test_compileCommandParallelism(t *testing.T) {
+	tests := []struct {
+		name        string
+		parallelism int
+		want        []string
+	}{
+		{name: "go default", parallelism: 0},
+		{name: "limited", parallelism: 2, want: []string{"-p", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{
+				Compiler:           "go",
+				OutputType:         "desktop",
+				Mode:               Production,
+				Platform:           "linux",
+				Arch:               "amd64",
+				CompileParallelism: tt.parallelism,
+				ProjectData:        &project.Project{},
+			}
+			_, args, err := compileCommand(options, "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			if index := lo.IndexOf(args, "-p"); index != -1 {
+				got = args[index : index+2]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compileCommand() -p args = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_OutputFilenameTemplate(t *testing.T) {
+	projectData := &project.Project{
+		OutputFilename: "myapp",
+		OutputType:     "desktop",
+		Info:           project.Info{ProductVersion: "1.2.3"},
+	}
+	tests := []struct {
+		name       string
+		template   string
+		outputFile string
+		platform   string
+		want       string
+	}{
+		{name: "linux", template: "{name}-{version}-{platform}-{arch}", platform: "linux", want: "myapp-1.2.3-linux-amd64"},
+		{name: "windows gets .exe", template: "{name}-{version}-{mode}", platform: "windows", want: "myapp-1.2.3-production.exe"},
+		{name: "output file wins", template: "{name}-{version}", outputFile: "custom", platform: "linux", want: "custom"},
+		{name: "no template", platform: "linux", want: "myapp-linux-amd64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{
+				Compiler:           "go",
+				Platform:           tt.platform,
+				Arch:               "amd64",
+				Mode:               Production,
+				OutputFile:         tt.outputFile,
+				OutputNameTemplate: tt.template,
+			}
+			builder := NewBaseBuilder(options)
+			builder.SetProjectData(projectData)
+			if got := builder.OutputFilename(options); got != tt.want {
+				t.Errorf("OutputFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateOutputNameTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		arch     string
+		wantErr  bool
+	}{
+		{template: "{name}-{version}-{platform}-{arch}", arch: "amd64,arm64"},
+		{template: "{name}-{version}", arch: "universal"},
+		{template: "{name}-{revision}", arch: "amd64", wantErr: true},
+		{template: "release/{name}", arch: "amd64", wantErr: true},
+		{template: "{name}:{version}", arch: "amd64", wantErr: true},
+		{template: "..", arch: "amd64", wantErr: true},
+		{template: "{name}-{version}", arch: "amd64,arm64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			problems := validateOutputNameTemplate(&Options{OutputNameTemplate: tt.template, Platform: "linux", Arch: tt.arch})
+			if (len(problems) > 0) != tt.wantErr {
+				t.Errorf("validateOutputNameTemplate() = %v, wantErr %v", problems, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_CompileProjectFromAnotherWorkingDir(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	projectDir := t.TempDir()
+	workingDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module example.com/app\n\ngo 1.18\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cgoEnabled := false
+	options := &Options{
+		Logger:       clilogger.New(io.Discard),
+		Compiler:     "go",
+		Platform:     runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		OutputType:   "desktop",
+		OutputFile:   "app",
+		CGOEnabled:   &cgoEnabled,
+		WorkingDir:   workingDir,
+		WailsJSDir:   filepath.Join(projectDir, "frontend"),
+		BinDirectory: filepath.Join(projectDir, "build", "bin"),
+		ProjectData:  &project.Project{Path: projectDir, BuildDir: "build", OutputType: "desktop"},
+	}
+	builder := NewBaseBuilder(options)
+	builder.SetProjectData(options.ProjectData)
+	if err := builder.CompileProject(options); err != nil {
+		t.Fatalf("CompileProject() error = %v", err)
+	}
+	if want := filepath.Join(projectDir, "build", "bin", "app"); options.CompiledBinary != want || !fs.FileExists(want) {
+		t.Errorf("CompileProject() CompiledBinary = %q, want %q to exist", options.CompiledBinary, want)
+	}
+	entries, err := os.ReadDir(workingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("CompileProject() wrote %d entries to the working directory, want none", len(entries))
+	}
+}
@@ -0,0 +1,61 @@
+package build
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// diffDirectories compares the files in the existing directory with the files in the
+// generated directory. The paths of files that differ, or only exist in one of them,
+// are returned relative to the directories. A missing existing directory is treated as empty.
+func diffDirectories(existingDir string, generatedDir string) ([]string, error) {
+	existing, err := readFiles(existingDir)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := readFiles(generatedDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for path, content := range generated {
+		if existingContent, ok := existing[path]; !ok || !bytes.Equal(content, existingContent) {
+			result = append(result, path)
+		}
+	}
+	for path := range existing {
+		if _, ok := generated[path]; !ok {
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// readFiles returns the contents of all the files in the given directory, keyed by their slash separated relative path
+func readFiles(dir string) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return result, nil
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		result[filepath.ToSlash(relPath)] = content
+		return nil
+	})
+	return result, err
+}
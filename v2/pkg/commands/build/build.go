@@ -1,17 +1,26 @@
 package build
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/wailsapp/wails/v2/internal/colour"
 	"github.com/wailsapp/wails/v2/internal/staticanalysis"
 	"github.com/wailsapp/wails/v2/pkg/commands/bindings"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/wailsapp/wails/v2/internal/fs"
 
@@ -21,6 +30,9 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/clilogger"
 )
 
+// DefaultEmbedPlaceholderName is the placeholder file created in empty embed directories unless another is given
+const DefaultEmbedPlaceholderName = ".gitkeep"
+
 // Mode is the type used to indicate the build modes
 type Mode int
 
@@ -33,63 +45,378 @@ const (
 	Debug
 )
 
+func (m Mode) String() string {
+	switch m {
+	case Dev:
+		return "dev"
+	case Production:
+		return "production"
+	case Debug:
+		return "debug"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (m *Mode) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "dev":
+		*m = Dev
+	case "production":
+		*m = Production
+	case "debug":
+		*m = Debug
+	default:
+		return fmt.Errorf("invalid mode '%s': must be one of dev, production or debug", text)
+	}
+	return nil
+}
+
+// Supported values for Options.CompressMethod
+const (
+	CompressNone               = "none"
+	CompressUPX                = "upx"
+	CompressSelfExtractingZstd = "self-extracting-zstd"
+)
+
 // Options contains all the build options as well as the project data
 type Options struct {
-	LDFlags           string               // Optional flags to pass to linker
-	UserTags          []string             // Tags to pass to the Go compiler
-	Logger            *clilogger.CLILogger // All output to the logger
-	OutputType        string               // EG: desktop, server....
-	Mode              Mode                 // release or dev
-	ProjectData       *project.Project     // The project data
-	Pack              bool                 // Create a package for the app after building
-	Platform          string               // The platform to build for
-	Arch              string               // The architecture to build for
-	Compiler          string               // The compiler command to use
-	SkipModTidy       bool                 //  Skip mod tidy before compile
-	IgnoreFrontend    bool                 // Indicates if the frontend does not need building
-	IgnoreApplication bool                 // Indicates if the application does not need building
-	OutputFile        string               // Override the output filename
-	BinDirectory      string               // Directory to use to write the built applications
-	CleanBinDirectory bool                 // Indicates if the bin output directory should be cleaned before building
-	CompiledBinary    string               // Fully qualified path to the compiled binary
-	KeepAssets        bool                 // Keep the generated assets/files
-	Verbosity         int                  // Verbosity level (0 - silent, 1 - default, 2 - verbose)
-	Compress          bool                 // Compress the final binary
-	CompressFlags     string               // Flags to pass to UPX
-	WebView2Strategy  string               // WebView2 installer strategy
-	RunDelve          bool                 // Indicates if we should run delve after the build
-	WailsJSDir        string               // Directory to generate the wailsjs module
-	ForceBuild        bool                 // Force
-	BundleName        string               // Bundlename for Mac
-	TrimPath          bool                 // Use Go's trimpath compiler flag
-	RaceDetector      bool                 // Build with Go's race detector
-	WindowsConsole    bool                 // Indicates that the windows console should be kept
-	Obfuscated        bool                 // Indicates that bound methods should be obfuscated
-	GarbleArgs        string               // The arguments for Garble
-	SkipBindings      bool                 // Skip binding generation
+	LDFlags                  string               // Optional flags to pass to linker
+	PlatformLDFlags          map[string]string    // Linker flags for some targets, keyed by GOOS, GOOS/* or GOOS/GOARCH, EG: {"windows": "-H windowsgui"}. Added after LDFlags. See platformLDFlags
+	LinkVars                 map[string]string    // Values of package level string variables set with -X ldflags, keyed by the qualified name, EG: {"main.version": "1.2.3"}. Added after the other ldflags
+	InjectBuildInfo          bool                 // Inject the git commit, dirty state and build time with -X ldflags. See buildInfoLDFlags
+	BuildInfoVarPrefix       string               // The package whose commit, dirty and buildTime variables are set by InjectBuildInfo. Defaults to main
+	ExtraGoFlags             []string             // Flags appended verbatim to `go build`, EG: -gcflags=all=-l
+	UserTags                 []string             // Tags to pass to the Go compiler
+	BindingsTags             []string             // Tags to generate the bindings with, EG: a codegen only tag. Defaults to UserTags
+	Logger                   *clilogger.CLILogger `json:"-"` // All output to the logger
+	OutputType               string               // EG: desktop, dev, server
+	BuildMode                string               // Empty to build an executable or c-shared to build a shared library and C header
+	Mode                     Mode                 // release or dev
+	ProjectData              *project.Project     `json:"-"` // The project data
+	Pack                     bool                 // Create a package for the app after building
+	Platform                 string               // The platform to build for
+	Arch                     string               // The architecture to build for. Comma separate multiple architectures
+	Compiler                 string               // The compiler command or path to the go binary to use. Defaults to "go"
+	MinGoVersion             string               // Fail the build early if the compiler is older than this Go version, EG: 1.21. Defaults to the oldest Go Wails supports
+	SkipModTidy              bool                 //  Skip mod tidy before compile
+	ModTidyMode              string               // run (default), skip or verify, which fails if the module isn't tidy without changing it
+	Offline                  bool                 // Forbid network access: sets GOPROXY=off, skips mod tidy and installs the frontend dependencies offline
+	UseVendor                bool                 // Build from the vendor directory with -mod=vendor. Skips mod tidy
+	IgnoreFrontend           bool                 // Indicates if the frontend does not need building
+	PrebuiltFrontendDir      string               // With IgnoreFrontend, this already built frontend is copied to the frontend's embedded directory, EG: frontend/dist. Relative to the project
+	FrontendArchive          bool                 // Archive the built frontend into the tarball the project embeds, EG: //go:embed frontend/dist.tar.gz archives frontend/dist
+	PrecompressAssets        string               // Write .gz or .br siblings of the compressible frontend assets before embedding them: none, gzip or brotli. Empty = none
+	AllowEmptyEmbeds         bool                 // Compile even if the frontend's embedded directory has no index.html
+	IgnoreApplication        bool                 // Indicates if the application does not need building
+	EntryPoint               string               // Directory of the main package to build, relative to the project. Defaults to the project root
+	OutputFile               string               // Override the output filename
+	OutputNameTemplate       string               // Output filename with {name}, {version}, {platform}, {arch} and {mode} placeholders, EG: {name}-{version}-{platform}-{arch}. OutputFile takes precedence
+	WorkingDir               string               // Directory relative paths, such as the BinDirectory, are resolved against instead of the process working directory
+	BinDirectory             string               // Directory to use to write the built applications. Defaults to the project's build/bin directory
+	CleanBinDirectory        bool                 // Indicates if the bin output directory should be cleaned before building
+	BackupBeforeClean        bool                 // Cleaning the bin directory moves its contents to a timestamped bin.bak-<time> directory next to it instead of deleting them
+	CleanBackupsToKeep       int                  // The number of bin directory backups to keep, the oldest being removed. 0 keeps them all
+	CompiledBinary           string               `json:"-"` // Fully qualified path to the compiled binary
+	CompiledBinaries         map[string]string    `json:"-"` // Fully qualified path to the compiled binary per arch for multi-arch builds
+	CompiledBundle           string               `json:"-"` // Fully qualified path to the application bundle, if one was packaged
+	KeepAssets               bool                 // Keep the generated assets/files
+	Verbosity                int                  // Verbosity level (0 - silent, 1 - default, 2 - verbose)
+	PhaseVerbosity           map[string]int       // Verbosity of the given phases, EG: {"compile": 2}. Phases not given use Verbosity
+	SuppressNotices          bool                 // Don't print the notices about experimental features, EG: in CI
+	CompressMethod           string               // How to compress the final binary: upx, none (default) or self-extracting-zstd
+	CompressFlags            string               // Flags to pass to UPX. Only used with the upx compress method
+	WebView2Strategy         string               // WebView2 installer strategy
+	RunDelve                 bool                 // Indicates if we should run delve after the build
+	WailsJSDir               string               // Directory to generate the wailsjs module
+	ForceBuild               bool                 // Force
+	BundleName               string               // Name of the Mac .app bundle, EG: MyApp Beta. Sets its CFBundleName and CFBundleDisplayName. Defaults to the project name
+	BundleArchSuffix         bool                 // Add the arch to the .app bundle directory name, EG: MyApp-arm64.app, when each arch is built separately. The bundle name is unchanged
+	ZipBundle                bool                 // Zip the Mac .app bundle, after signing and notarization, to <name>.app.zip in the bin directory
+	MacIconFile              string               // The macOS icon, a .icns or a .png to convert. Relative to the project. Defaults to appicon.png
+	MacMinVersion            string               // The minimum macOS version to build for, EG: 11.0. Sets MACOSX_DEPLOYMENT_TARGET and LSMinimumSystemVersion. Defaults to 10.13
+	MacPlistExtras           PlistExtras          // Extra keys for the Mac Info.plist, EG: NSCameraUsageDescription. Replaces the keys already in it
+	MacSigningIdentity       string               // The identity to sign the Mac .app bundle with. Empty = don't sign
+	MacEntitlementsFile      string               // Entitlements to sign the Mac .app bundle with. Relative to the project
+	NotarizeProfile          string               // The notarytool keychain profile used to notarize the signed Mac .app bundle. Empty = don't notarize
+	TrimPath                 bool                 // Use Go's trimpath compiler flag
+	Reproducible             bool                 // Produce byte-identical builds of the same commit. See reproducible.go for the measures applied
+	RaceDetector             bool                 // Build with Go's race detector
+	CompileParallelism       int                  // The number of packages go build compiles in parallel (-p). 0 = Go's default, the number of CPUs
+	CC                       string               // The C compiler used by CGO, EG: x86_64-w64-mingw32-gcc. ${arch} is replaced with the arch being compiled
+	CXX                      string               // The C++ compiler used by CGO. ${arch} is replaced with the arch being compiled
+	CGOEnabled               *bool                // Sets CGO_ENABLED if not nil. By default CGO is enabled for all platforms except Windows
+	WindowsConsole           bool                 // Indicates that the windows console should be kept. Otherwise -H windowsgui is added to the ldflags unless they have a -H
+	EmbedWindowsMetadata     bool                 // Embed the Windows icon, manifest and version info even when not packing
+	WindowsIconFile          string               // The Windows icon, a .ico or a .png to convert. Relative to the project. Defaults to windows/icon.ico
+	WindowsManifestFile      string               // Custom application manifest to embed on Windows. Relative to the project. Defaults to windows/wails.exe.manifest
+	Obfuscated               bool                 // Indicates that bound methods should be obfuscated
+	GarbleArgs               string               // The arguments for Garble
+	ObfuscationExclude       []string             // Modules that Garble should not obfuscate, EG: github.com/foo/bar. Sets GOGARBLE
+	SkipBindings             bool                 // Skip binding generation
+	SequentialUniversalBuild bool                 // Build the darwin universal targets one after the other rather than concurrently
+	LipoPath                 string               // The lipo used to create darwin universal binaries, EG: llvm-lipo. Defaults to lipo if installed, else they are merged without it
+	KeepUniversalSlices      bool                 // Keep the amd64 and arm64 binaries of a universal build, EG: app-amd64 and app-arm64
+	DryRun                   bool                 // Print the compile commands without executing them
+	ManifestFile             string               // If set, a JSON BuildManifest is written to this file after a successful build
+	EmitResultJSON           bool                 // Write a WAILS_BUILD_RESULT={...} line with the binary, bundle, platform and arch to stdout after a successful build, for tools
+	GenerateChecksums        bool                 // Write a SHA256SUMS file of the binaries and packages to the bin directory
+	GPGSigningKey            string               // If set, the SHA256SUMS file is signed with this GPG key to SHA256SUMS.asc
+	HookTimeout              time.Duration        // Maximum time a build hook may run for. 0 = no timeout
+	HookOutputFile           string               // If set, the output of every build hook is appended to this file
+	LinuxIconFile            string               // The .png icon of Linux and FreeBSD packages. Relative to the project. Defaults to appicon.png
+	LinuxPackageFormat       string               // The package to create when packing for Linux: appimage or deb. Empty = no package
+	LinuxFileModes           map[string]string    // Octal modes of files in Linux packages by path pattern, EG: {"usr/share/applications/*": "0644"}. The binary is always executable
+	SkipFrontendIfUnchanged  bool                 // Skip building the frontend if its sources haven't changed since the last build
+	FrontendBuildRetries     int                  // Number of times to retry the frontend build if a command exits with a non-zero status
+	FrontendPackageManager   string               // The package manager used for npm install and build commands: npm, pnpm, bun or yarn. Detected from the lockfile if empty
+	FrontendHashIgnore       []string             // Frontend directory names excluded from the change detection. Defaults to dist and build
+	Timings                  BuildTimings         `json:"-"` // The time taken by each phase of the build. Populated by Build
+	RunVet                   bool                 // Run `go vet ./...` with the build's tags before compiling and fail the build on any problems
+	AutoCleanCacheOnStale    bool                 // If the compile fails because the go build cache is stale, run `go clean -cache` and retry once
+	FailOnWarnings           bool                 // Fail the build if the compiler reports warnings, EG: from the C compiler or linker used by cgo
+	TrackSize                bool                 // Print the binary size and its change since the last build. Sizes are recorded in the project build directory
+	VerifyBinary             bool                 // Check the compiled binary is a valid executable for the target platform
+	StripSymbols             bool                 // Strip the symbol table and debug information (-w -s). Ignored in debug mode
+	PGOProfile               string               // Profile for profile-guided optimization, relative to the project, or auto for the main package's default.pgo. Requires Go 1.21
+	AMD64Level               string               // The GOAMD64 microarchitecture level (v1-v4) for amd64 builds
+	GOARM                    string               // The GOARM version (5, 6 or 7) for 32-bit arm builds. 7 uses hardware floating point
+	OptimizeFor              string               // Apply the defaults of a build profile: size or speed. See applyOptimizationProfile
+	EnableBuildCache         bool                 // Reuse a previously compiled binary if the project and options are unchanged
+	MinFreeDiskBytes         uint64               // Fail before building if the bin directory's volume has less free space than this. 0 = no check
+	EmbedPlaceholderName     string               // File created in empty embed directories. Defaults to DefaultEmbedPlaceholderName
+	NoEmbedPlaceholder       bool                 // Don't create a placeholder in empty embed directories
+	BindingsCheckOnly        bool                 // Fail if the generated bindings differ from the existing ones, rather than overwriting them
+	CleanBindings            bool                 // Clean also removes the generated wailsjs bindings and runtime. See Clean
+	BindingsOutputDir        string               // Directory to generate the bindings' wailsjs module in. Relative to the project. Defaults to WailsJSDir
+	BindingsSchemaFile       string               // If set, a JSON description of the bound methods and models is written to this file. Relative to the project
+	ProgressFunc             func(BuildEvent)     `json:"-"` // If set, called at each milestone of the build
+	GeneratedArtifacts       []string             `json:"-"` // The files and directories the build generated that remain after it. See addArtifact
+
+	ctx          context.Context // Cancels the build when done. Set by BuildWithContext
+	buildInfo    string          // The build info ldflags, resolved once per build
+	sourceDate   time.Time       // The timestamp of reproducible builds. See resolveSourceDate
+	bundleSuffix string          // Added to the .app bundle directory name, EG: -arm64 for multi-arch builds
+	phase        string          // The phase of the build that is running, given to the OnBuildFailed hook
+}
+
+// buildContext returns the context of the build
+func (o *Options) buildContext() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// workingDir returns the directory relative paths of the build are resolved against:
+// the WorkingDir, made absolute, or the process working directory if none was given
+func (o *Options) workingDir() (string, error) {
+	if o.WorkingDir == "" {
+		return os.Getwd()
+	}
+	return filepath.Abs(o.WorkingDir)
+}
+
+// verbosity returns the verbosity of the running phase: its PhaseVerbosity if given, else Verbosity
+func (o *Options) verbosity() int {
+	if verbosity, ok := o.PhaseVerbosity[o.phase]; ok {
+		return verbosity
+	}
+	return o.Verbosity
+}
+
+// cloneForTarget returns a copy of the options for compiling the given arch to the given output file.
+// The copy owns its own UserTags so concurrent compiles don't share the slice, and starts with no GeneratedArtifacts.
+func (o *Options) cloneForTarget(arch string, outputFile string) *Options {
+	result := *o
+	result.Arch = arch
+	result.OutputFile = outputFile
+	result.CleanBinDirectory = false
+	result.UserTags = append([]string{}, o.UserTags...)
+	result.GeneratedArtifacts = nil
+	return &result
 }
 
 // Build the project!
 func Build(options *Options) (string, error) {
+	return BuildWithContext(context.Background(), options)
+}
+
+// BuildWithContext builds the project like Build. When the given context is done,
+// any running commands are killed and the context's error is returned.
+// Several builds, of the same project or not, can run concurrently as long as each has its own Options.
+func BuildWithContext(ctx context.Context, options *Options) (string, error) {
+	options.ctx = ctx
+
+	// The build updates the project data, so it works on its own copy to let builds of the same
+	// project run concurrently. The caller's project data is left unchanged.
+	if sharedProjectData := options.ProjectData; sharedProjectData != nil {
+		projectData := *sharedProjectData
+		options.ProjectData = &projectData
+		defer func() {
+			options.ProjectData = sharedProjectData
+		}()
+	}
+	// Tags are added during the build, which mustn't reach a slice shared with other options
+	options.UserTags = append([]string{}, options.UserTags...)
+	// A silent build prints nothing but its errors, even when given a logger that isn't muted
+	if sharedLogger := options.Logger; sharedLogger != nil && options.Verbosity == 0 {
+		options.Logger = clilogger.New(sharedLogger.Writer)
+		options.Logger.Mute(true)
+		defer func() {
+			options.Logger = sharedLogger
+		}()
+	}
+
+	result, err := build(options)
+	if err != nil {
+		runBuildFailedHook(options, err)
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return result, err
+}
+
+// runBuildFailedHook executes the project's OnBuildFailed hook for the given build error, with ${phase}
+// replaced by the phase that failed. It runs even if the build was cancelled, as it is there to clean up.
+// A failure of the hook itself is only reported, so the build error is returned.
+func runBuildFailedHook(options *Options, err error) {
+	if options.DryRun || options.ProjectData == nil || options.ProjectData.OnBuildFailed == "" {
+		return
+	}
+	phase := options.phase
+	var hookError *HookError
+	var packagingError *PackagingError
+	switch {
+	case errors.As(err, &hookError):
+		phase = hookError.Phase + " hook"
+	case errors.As(err, &packagingError):
+		phase = PhasePackaging
+	}
+
+	// The hook runs in the bin directory like the others, or the project directory if the build failed before creating it
+	hookOptions := *options
+	hookOptions.ctx = context.Background()
+	if !fs.DirExists(hookOptions.BinDirectory) {
+		hookOptions.BinDirectory = options.ProjectData.Path
+	}
+	hookArgs := hookArguments(options)
+	hookArgs["${phase}"] = phase
+	if hookErr := executeBuildHook(options.Logger, &hookOptions, "", hookArgs, options.ProjectData.OnBuildFailed, "build failed"); hookErr != nil {
+		options.Logger.Println("Warning: the build failed hook failed: %s", hookErr)
+	}
+}
+
+func build(options *Options) (string, error) {
 
 	// Extract logger
 	outputLogger := options.Logger
+	options.phase = PhaseSetup
 
 	// Get working directory
-	cwd, err := os.Getwd()
+	cwd, err := options.workingDir()
 	if err != nil {
 		return "", err
 	}
 
+	if options.Compiler == "" {
+		options.Compiler = "go"
+	}
+	// Race-instrumented binaries are many times larger and slower, so they must never be shipped
+	if options.RaceDetector && options.Mode == Production {
+		outputLogger.Println("Warning: the race detector cannot be used for production builds and has been DISABLED. Use a dev or debug build to detect races.")
+		options.RaceDetector = false
+	}
+	if err := validateOptions(options); err != nil {
+		return "", err
+	}
+
+	applyOptimizationProfile(options)
+
+	if options.Reproducible {
+		options.TrimPath = true
+		options.sourceDate, err = resolveSourceDate(options)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	options.buildInfo = ""
+	if options.InjectBuildInfo {
+		options.buildInfo = buildInfoLDFlags(options, options.buildTimestamp())
+	}
+
+	if options.CGOEnabled != nil && !*options.CGOEnabled && options.OutputType != "server" && options.Platform != "windows" {
+		outputLogger.Println("Warning: the %s webview requires CGO. The application will not build or run without it.", options.Platform)
+	}
+
+	if options.AMD64Level != "" {
+		if !lo.Contains(strings.Split(options.Arch, ","), "amd64") {
+			outputLogger.Println("Warning: AMD64 level is only used for amd64 builds. Ignoring.")
+			options.AMD64Level = ""
+		}
+	}
+
+	if options.GOARM != "" {
+		if !lo.Contains(strings.Split(options.Arch, ","), "arm") {
+			outputLogger.Println("Warning: GOARM is only used for arm builds. Ignoring.")
+			options.GOARM = ""
+		}
+	}
+
+	if !options.DryRun {
+		if err := checkGoVersion(options); err != nil {
+			return "", err
+		}
+	}
+	// Fail fast if we can't compress the binary once it's built
+	if options.CompressMethod == CompressUPX && !options.DryRun {
+		if err := checkUPX(options); err != nil {
+			return "", err
+		}
+	}
+	if options.Obfuscated && !options.DryRun {
+		if err := checkGarble(options); err != nil {
+			return "", err
+		}
+	}
+	if options.UseVendor && !options.DryRun {
+		if err := checkVendor(options); err != nil {
+			return "", err
+		}
+	}
+	if options.PGOProfile != "" && !options.DryRun {
+		checkPGO(options)
+	}
+
 	// wails js dir
 	options.WailsJSDir = options.ProjectData.GetWailsJSDir()
 
-	// Set build directory
-	options.BinDirectory = filepath.Join(options.ProjectData.GetBuildDir(), "bin")
+	resolveBinDirectory(options, cwd)
+	for _, file := range []*string{&options.ManifestFile, &options.HookOutputFile} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(cwd, *file)
+		}
+	}
+
+	if !options.DryRun {
+		if err := checkFreeDiskSpace(options); err != nil {
+			return "", err
+		}
+	}
 
 	// Save the project type
 	options.ProjectData.OutputType = options.OutputType
 
+	options.Timings = BuildTimings{}
+	options.GeneratedArtifacts = nil
+
 	// Create builder
 	var builder Builder
 
@@ -98,6 +425,8 @@ func Build(options *Options) (string, error) {
 		builder = newDesktopBuilder(options)
 	case "dev":
 		builder = newDesktopBuilder(options)
+	case "server":
+		builder = newServerBuilder(options)
 	default:
 		return "", fmt.Errorf("cannot build assets for output type %s", options.ProjectData.OutputType)
 	}
@@ -108,11 +437,20 @@ func Build(options *Options) (string, error) {
 	// Initialise Builder
 	builder.SetProjectData(options.ProjectData)
 
-	hookArgs := map[string]string{
-		"${platform}": options.Platform + "/" + options.Arch,
+	hookArgs := hookArguments(options)
+
+	// In dry run mode, only the compile commands are reported
+	if options.DryRun {
+		return execBuildApplication(builder, options)
+	}
+
+	if options.ProjectData.GlobalPreBuildHook != "" {
+		if err := executeBuildHook(outputLogger, options, "", hookArgs, options.ProjectData.GlobalPreBuildHook, "global pre"); err != nil {
+			return "", err
+		}
 	}
 
-	for _, hook := range []string{options.Platform + "/" + options.Arch, options.Platform + "/*", "*/*"} {
+	for _, hook := range hookIdentifiers(options) {
 		if err := execPreBuildHook(outputLogger, options, hook, hookArgs); err != nil {
 			return "", err
 		}
@@ -122,37 +460,137 @@ func Build(options *Options) (string, error) {
 	if err := CreateEmbedDirectories(cwd, options); err != nil {
 		return "", err
 	}
+	frontendArchive, frontendArchiveSource := "", ""
+	if options.FrontendArchive {
+		frontendArchive, frontendArchiveSource, err = frontendArchiveEmbed(options)
+		if err != nil {
+			return "", err
+		}
+		// The bindings compile the application, which needs the archive to exist
+		if !fs.FileExists(frontendArchive) {
+			if err := writeFrontendArchive(options, frontendArchive, frontendArchiveSource); err != nil {
+				return "", err
+			}
+		}
+	}
 
 	// Generate bindings
+	if err := options.buildContext().Err(); err != nil {
+		return "", err
+	}
 	if !options.SkipBindings {
+		options.phase = PhaseBindings
+		start := time.Now()
 		err = GenerateBindings(options)
 		if err != nil {
-			return "", err
+			return "", &BindingsError{Err: err}
 		}
+		options.Timings.record(PhaseBindings, start)
+		options.reportProgress(PhaseBindings, "Bindings generated", 20)
 	}
 
+	if err := options.buildContext().Err(); err != nil {
+		return "", err
+	}
 	if !options.IgnoreFrontend {
-		err = builder.BuildFrontend(outputLogger)
+		options.phase = PhaseFrontend
+		start := time.Now()
+		err = buildFrontend(builder, options)
 		if err != nil {
-			return "", err
+			return "", &FrontendBuildError{Err: err}
+		}
+		options.Timings.record(PhaseFrontend, start)
+		options.reportProgress(PhaseFrontend, "Frontend built", 40)
+	} else if options.PrebuiltFrontendDir != "" {
+		options.phase = PhaseFrontend
+		outputLogger.Print("  - Copying prebuilt frontend: ")
+		if err := copyPrebuiltFrontend(options); err != nil {
+			return "", &FrontendBuildError{Err: err}
 		}
+		outputLogger.Println("Done.")
+	}
+	if options.PrecompressAssets != "" && options.PrecompressAssets != PrecompressNone {
+		options.phase = PhaseFrontend
+		outputLogger.Print("  - Compressing frontend assets: ")
+		distDir := frontendArchiveSource
+		if distDir == "" {
+			distDir, err = frontendEmbedDir(options)
+			if err != nil {
+				return "", &FrontendBuildError{Err: err}
+			}
+		}
+		if err := precompressAssets(options, distDir); err != nil {
+			return "", &FrontendBuildError{Err: err}
+		}
+		outputLogger.Println("Done.")
+	}
+	if options.FrontendArchive {
+		options.phase = PhaseFrontend
+		outputLogger.Print("  - Archiving frontend: ")
+		if err := writeFrontendArchive(options, frontendArchive, frontendArchiveSource); err != nil {
+			return "", &FrontendBuildError{Err: err}
+		}
+		outputLogger.Println("Done.")
 	}
 
+	if err := options.buildContext().Err(); err != nil {
+		return "", err
+	}
 	compileBinary := ""
 	if !options.IgnoreApplication {
+		options.phase = PhaseCompile
 		compileBinary, err = execBuildApplication(builder, options)
 		if err != nil {
 			return "", err
 		}
 	}
 
+	if options.Verbosity == VERBOSE {
+		options.Timings.printSummary(options)
+	}
+
+	options.phase = PhasePostBuild
 	hookArgs["${bin}"] = compileBinary
-	for _, hook := range []string{options.Platform + "/" + options.Arch, options.Platform + "/*", "*/*"} {
+	for _, hook := range hookIdentifiers(options) {
 		if err := execPostBuildHook(outputLogger, options, hook, hookArgs); err != nil {
 			return "", err
 		}
 	}
 
+	if options.GenerateChecksums && !options.IgnoreApplication && !options.DryRun {
+		outputLogger.Print("  - Generating checksums: ")
+		if err := writeChecksums(options); err != nil {
+			return "", err
+		}
+		outputLogger.Println("Done.")
+	}
+
+	if options.TrackSize && !options.IgnoreApplication && !options.DryRun {
+		if err := reportBinarySize(options); err != nil {
+			return "", err
+		}
+	}
+
+	if options.ManifestFile != "" && !options.IgnoreApplication {
+		if err := writeBuildManifest(options); err != nil {
+			return "", err
+		}
+	}
+
+	if options.ProjectData.GlobalPostBuildHook != "" {
+		if err := executeBuildHook(outputLogger, options, "", hookArgs, options.ProjectData.GlobalPostBuildHook, "global post"); err != nil {
+			return "", err
+		}
+	}
+
+	if options.EmitResultJSON {
+		if err := writeBuildResult(os.Stdout, options, compileBinary); err != nil {
+			return "", err
+		}
+	}
+
+	options.reportProgress(PhaseComplete, "Build complete", 100)
+
 	return compileBinary, nil
 }
 
@@ -165,19 +603,35 @@ func CreateEmbedDirectories(cwd string, buildOptions *Options) error {
 	if err != nil {
 		return err
 	}
+	return createEmbedDirectories(buildOptions, embedDetails)
+}
 
+// createEmbedDirectories creates the directories of the given embeds. The directives are logged in verbose mode
+func createEmbedDirectories(buildOptions *Options, embedDetails []*staticanalysis.EmbedDetails) error {
+	verbose := buildOptions.Logger != nil && buildOptions.verbosity() == VERBOSE
 	for _, embedDetail := range embedDetails {
-		fullPath := embedDetail.GetFullPath()
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			err := os.MkdirAll(fullPath, 0755)
-			if err != nil {
-				return err
+		pattern := embedDetail.EmbedPath
+		if embedDetail.All {
+			pattern = "all:" + pattern
+		}
+		// Files can't be created up front, only directories
+		if embedDetail.IsFile {
+			if verbose {
+				buildOptions.Logger.Println("  Embed directive in %s: %s (%s, file)", embedDetail.SourceFile, pattern, embedDetail.GetFullPath())
 			}
-			f, err := os.Create(filepath.Join(fullPath, "gitkeep"))
-			if err != nil {
-				return err
+			continue
+		}
+		existed := fs.DirExists(embedDetail.GetFullPath())
+		err := createEmbedDirectory(embedDetail.GetFullPath(), buildOptions.embedPlaceholderName())
+		if err != nil {
+			return err
+		}
+		if verbose {
+			state := "created"
+			if existed {
+				state = "exists"
 			}
-			_ = f.Close()
+			buildOptions.Logger.Println("  Embed directive in %s: %s (%s, %s)", embedDetail.SourceFile, pattern, embedDetail.GetFullPath(), state)
 		}
 	}
 
@@ -185,41 +639,189 @@ func CreateEmbedDirectories(cwd string, buildOptions *Options) error {
 
 }
 
+// embedPlaceholderName returns the placeholder file to create in empty embed directories, empty if none
+func (o *Options) embedPlaceholderName() string {
+	if o.NoEmbedPlaceholder {
+		return ""
+	}
+	name, _ := lo.Coalesce(o.EmbedPlaceholderName, DefaultEmbedPlaceholderName)
+	return name
+}
+
+// createEmbedDirectory makes sure the given embed directory exists. If the directory is
+// empty, a placeholder file with the given name is created so that the embed succeeds.
+func createEmbedDirectory(fullPath string, placeholderName string) error {
+	err := os.MkdirAll(fullPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	if placeholderName == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(fullPath)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return nil
+	}
+
+	f, err := os.Create(filepath.Join(fullPath, placeholderName))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
 func GenerateBindings(buildOptions *Options) error {
 
 	obfuscated := buildOptions.Obfuscated
-	if obfuscated {
+	switch {
+	case buildOptions.BindingsCheckOnly:
+		buildOptions.Logger.Print("  - Checking bindings: ")
+	case obfuscated:
 		buildOptions.Logger.Print("  - Generating obfuscated bindings: ")
-		buildOptions.UserTags = append(buildOptions.UserTags, "obfuscated")
-	} else {
+	default:
 		buildOptions.Logger.Print("  - Generating bindings: ")
 	}
 
+	outputDir := bindingsOutputDir(buildOptions)
+
+	schemaFile := buildOptions.BindingsSchemaFile
+	if schemaFile != "" && !filepath.IsAbs(schemaFile) {
+		schemaFile = filepath.Join(buildOptions.ProjectData.Path, schemaFile)
+	}
+
+	// In check only mode, the bindings are generated in a temporary directory and compared
+	// with the existing ones
+	generateDir := outputDir
+	if buildOptions.BindingsCheckOnly {
+		tempDir, err := os.MkdirTemp("", "wailsjs")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.RemoveAll(tempDir)
+		}()
+		generateDir = tempDir
+	} else if buildOptions.BindingsOutputDir != "" {
+		if err := fs.MkDirs(outputDir); err != nil {
+			return err
+		}
+	}
+
 	// Generate Bindings
 	output, err := bindings.GenerateBindings(bindings.Options{
-		Tags:      buildOptions.UserTags,
-		GoModTidy: !buildOptions.SkipModTidy,
+		Tags:             bindingsTags(buildOptions),
+		ProjectDirectory: buildOptions.ProjectData.Path,
+		GoModTidy:        buildOptions.modTidyMode() == ModTidyRun,
+		Compiler:         buildOptions.Compiler,
+		OutputDirectory:  generateDir,
+		SchemaFile:       schemaFile,
+		GoEnv:            goEnvChanges(buildOptions),
+		Context:          buildOptions.buildContext(),
 	})
 	if err != nil {
 		return err
 	}
 
-	if buildOptions.Verbosity == VERBOSE {
+	if buildOptions.verbosity() == VERBOSE {
 		buildOptions.Logger.Println(output)
 	}
 
+	if buildOptions.BindingsCheckOnly {
+		changed, err := diffDirectories(filepath.Join(outputDir, "wailsjs"), filepath.Join(generateDir, "wailsjs"))
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			return fmt.Errorf("generated bindings are out of date:\n  - %s", strings.Join(changed, "\n  - "))
+		}
+	}
+
 	buildOptions.Logger.Println("Done.")
 
 	return nil
 }
 
+// bindingsTags returns the tags to generate the bindings with: BindingsTags or, if not given, UserTags.
+// Obfuscated bindings add the obfuscated tag to a copy, leaving the options' tags unchanged
+func bindingsTags(options *Options) []string {
+	tags := options.BindingsTags
+	if tags == nil {
+		tags = options.UserTags
+	}
+	tags = append([]string{}, tags...)
+	if options.Obfuscated {
+		tags = append(tags, "obfuscated")
+	}
+	return tags
+}
+
+// GenerateBindingsOnly generates the wailsjs bindings of the project in projectPath without building it,
+// EG: to regenerate them when a file is saved. The bindings are generated with the given build tags,
+// without running go mod tidy, in outputDir or the project's wailsjsdir if outputDir is empty.
+func GenerateBindingsOnly(projectPath string, tags []string, outputDir string) error {
+	projectPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return err
+	}
+	projectData, err := project.Load(projectPath)
+	if err != nil {
+		return err
+	}
+	projectData.Path = projectPath
+
+	options := &Options{
+		Logger:            clilogger.New(io.Discard),
+		ProjectData:       projectData,
+		UserTags:          tags,
+		Compiler:          "go",
+		ModTidyMode:       ModTidySkip,
+		WailsJSDir:        projectData.GetWailsJSDir(),
+		BindingsOutputDir: outputDir,
+	}
+	if err := GenerateBindings(options); err != nil {
+		return &BindingsError{Err: err}
+	}
+	return nil
+}
+
+// bindingsOutputDir returns the directory the wailsjs bindings module is generated in
+func bindingsOutputDir(options *Options) string {
+	if options.BindingsOutputDir == "" {
+		return options.WailsJSDir
+	}
+	if filepath.IsAbs(options.BindingsOutputDir) {
+		return options.BindingsOutputDir
+	}
+	return filepath.Join(options.ProjectData.Path, options.BindingsOutputDir)
+}
+
+// resolveBinDirectory sets the directory to write the built applications to. A caller supplied directory takes
+// precedence over the project's build/bin directory and relative paths are resolved against the given directory.
+// All output, including the intermediate universal binaries, is written here.
+func resolveBinDirectory(options *Options, cwd string) {
+	if options.BinDirectory == "" {
+		options.BinDirectory = filepath.Join(options.ProjectData.GetBuildDir(), "bin")
+	} else if !filepath.IsAbs(options.BinDirectory) {
+		options.BinDirectory = filepath.Join(cwd, options.BinDirectory)
+	}
+}
+
 func execBuildApplication(builder Builder, options *Options) (string, error) {
 	// Extract logger
 	outputLogger := options.Logger
 
+	if archs := strings.Split(options.Arch, ","); len(archs) > 1 {
+		return execMultiArchBuild(builder, options, archs)
+	}
+
 	// If we are building for windows, we will need to generate the asset bundle before
 	// compilation. This will be a .syso file in the project root
-	if options.Pack && options.Platform == "windows" {
+	if (options.Pack || options.EmbedWindowsMetadata) && options.Platform == "windows" && !options.isSharedLibrary() && !options.DryRun {
 		outputLogger.Print("  - Generating bundle assets: ")
 		err := packageApplicationForWindows(options)
 		if err != nil {
@@ -228,87 +830,232 @@ func execBuildApplication(builder Builder, options *Options) (string, error) {
 		outputLogger.Println("Done.")
 
 		// When we finish, we will want to remove the syso file
-		defer func() {
-			err := os.Remove(filepath.Join(options.ProjectData.Path, options.ProjectData.Name+"-res.syso"))
-			if err != nil {
-				log.Fatal(err)
+		if !options.KeepAssets {
+			defer func() {
+				err := os.Remove(filepath.Join(options.ProjectData.Path, options.ProjectData.Name+"-res.syso"))
+				if err != nil {
+					log.Fatal(err)
+				}
+			}()
+		}
+	}
+
+	hookArgs := hookArguments(options)
+	if !options.DryRun {
+		for _, hook := range hookIdentifiers(options) {
+			if err := execPreCompileHook(outputLogger, options, hook, hookArgs); err != nil {
+				return "", err
 			}
-		}()
+		}
+	}
+
+	if options.RunVet && !options.DryRun {
+		outputLogger.Print("  - Running go vet: ")
+		if err := runVet(options); err != nil {
+			return "", err
+		}
+		outputLogger.Println("Done.")
+	}
+
+	if !options.AllowEmptyEmbeds && !options.DryRun {
+		if err := checkFrontendAssets(options); err != nil {
+			return "", err
+		}
 	}
 
 	// Compile the application
-	outputLogger.Print("  - Compiling application: ")
+	compileStart := time.Now()
+	options.reportProgress(PhaseCompile, "Compiling application", 40)
+	if options.DryRun {
+		outputLogger.Println("  - Compiling application (dry run):")
+	} else {
+		outputLogger.Print("  - Compiling application: ")
+	}
 
 	if options.Platform == "darwin" && options.Arch == "universal" {
+		// Check the given lipo is available before compiling both targets. Without one, lipo is
+		// used if installed and the binaries are merged by writeFatBinary otherwise
+		lipoPath := options.LipoPath
+		if lipoPath != "" && !options.DryRun {
+			if _, err := exec.LookPath(lipoPath); err != nil {
+				return "", fmt.Errorf("lipo not found at '%s': %w", lipoPath, err)
+			}
+		}
+		if lipoPath == "" {
+			if _, err := exec.LookPath("lipo"); err == nil {
+				lipoPath = "lipo"
+			}
+		}
 		outputFile := builder.OutputFilename(options)
 		amd64Filename := outputFile + "-amd64"
 		arm64Filename := outputFile + "-arm64"
 
-		// Build amd64 first
-		options.Arch = "amd64"
-		options.OutputFile = amd64Filename
-		options.CleanBinDirectory = false
-		if options.Verbosity == VERBOSE {
-			outputLogger.Println("\nBuilding AMD64 Target: %s", filepath.Join(options.BinDirectory, options.OutputFile))
-		}
-		err := builder.CompileProject(options)
-		if err != nil {
-			return "", err
-		}
-		// Build arm64
-		options.Arch = "arm64"
-		options.OutputFile = arm64Filename
-		options.CleanBinDirectory = false
-		if options.Verbosity == VERBOSE {
-			outputLogger.Println("Building ARM64 Target: %s", filepath.Join(options.BinDirectory, options.OutputFile))
+		amd64Options := options.cloneForTarget("amd64", amd64Filename)
+		arm64Options := options.cloneForTarget("arm64", arm64Filename)
+		if options.verbosity() == VERBOSE {
+			outputLogger.Println("\nBuilding AMD64 Target: %s", filepath.Join(options.BinDirectory, amd64Options.OutputFile))
+			outputLogger.Println("Building ARM64 Target: %s", filepath.Join(options.BinDirectory, arm64Options.OutputFile))
 		}
-		err = builder.CompileProject(options)
 
-		if err != nil {
-			return "", err
+		if options.SequentialUniversalBuild {
+			err := compileWithStaleCacheRetry(options, func() error {
+				for _, targetOptions := range []*Options{amd64Options, arm64Options} {
+					if err := compileProjectWithCache(builder, targetOptions); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return "", err
+			}
+		} else {
+			// Both targets share the same go.mod, so tidy it once rather than concurrently
+			if options.modTidyMode() != ModTidySkip && !options.DryRun {
+				err := runModTidy(options)
+				if err != nil {
+					return "", err
+				}
+				amd64Options.SkipModTidy = true
+				arm64Options.SkipModTidy = true
+			}
+			err := compileWithStaleCacheRetry(options, func() error {
+				// Each target logs to its own buffer, flushed in order once both are done,
+				// so the concurrent compiles don't write to the logger at the same time
+				var targets errgroup.Group
+				targetOutput := make([]bytes.Buffer, 2)
+				for i, targetOptions := range []*Options{amd64Options, arm64Options} {
+					targetOptions := targetOptions
+					targetOptions.Logger = clilogger.New(&targetOutput[i])
+					targets.Go(func() error {
+						return compileProjectWithCache(builder, targetOptions)
+					})
+				}
+				err := targets.Wait()
+				for i := range targetOutput {
+					outputLogger.Print("%s", targetOutput[i].String())
+				}
+				amd64Options.Logger = outputLogger
+				arm64Options.Logger = outputLogger
+				return err
+			})
+			if err != nil {
+				return "", err
+			}
 		}
+
 		// Run lipo
-		if options.Verbosity == VERBOSE {
-			outputLogger.Println("  Running lipo: lipo -create -output %s %s %s", outputFile, amd64Filename, arm64Filename)
+		lipoArgs := []string{"-create", "-output", outputFile, amd64Filename, arm64Filename}
+		if options.DryRun {
+			if lipoPath != "" {
+				logDryRun(options, options.BinDirectory, lipoPath, lipoArgs)
+			} else {
+				outputLogger.Println("  Dry run: merge %s and %s into %s without lipo", amd64Filename, arm64Filename, outputFile)
+			}
+			options.CompiledBinary = filepath.Join(options.BinDirectory, outputFile)
+			return options.CompiledBinary, nil
 		}
-		_, stderr, err := shell.RunCommand(options.BinDirectory, "lipo", "-create", "-output", outputFile, amd64Filename, arm64Filename)
-		if err != nil {
-			return "", fmt.Errorf("%s - %s", err.Error(), stderr)
+		if lipoPath != "" {
+			if options.verbosity() == VERBOSE {
+				outputLogger.Println("  Running lipo: %s %s", lipoPath, strings.Join(lipoArgs, " "))
+			}
+			_, stderr, err := shell.RunCommandWithContext(options.buildContext(), options.BinDirectory, lipoPath, lipoArgs...)
+			if err != nil {
+				return "", fmt.Errorf("%s - %s", err.Error(), stderr)
+			}
+		} else {
+			if options.verbosity() == VERBOSE {
+				outputLogger.Println("  lipo not found, merging the binaries into %s", outputFile)
+			}
+			err := writeFatBinary(filepath.Join(options.BinDirectory, outputFile), filepath.Join(options.BinDirectory, amd64Filename), filepath.Join(options.BinDirectory, arm64Filename))
+			if err != nil {
+				return "", err
+			}
 		}
 		// Remove temp binaries
-		err = fs.DeleteFile(filepath.Join(options.BinDirectory, amd64Filename))
-		if err != nil {
-			return "", err
-		}
-		err = fs.DeleteFile(filepath.Join(options.BinDirectory, arm64Filename))
-		if err != nil {
-			return "", err
+		for _, filename := range []string{amd64Filename, arm64Filename} {
+			if options.KeepAssets || options.KeepUniversalSlices {
+				options.addArtifact(filepath.Join(options.BinDirectory, filename))
+				continue
+			}
+			err := fs.DeleteFile(filepath.Join(options.BinDirectory, filename))
+			if err != nil {
+				return "", err
+			}
 		}
 		options.ProjectData.OutputFilename = outputFile
 		options.CompiledBinary = filepath.Join(options.BinDirectory, outputFile)
 	} else {
-		err := builder.CompileProject(options)
+		err := compileWithStaleCacheRetry(options, func() error {
+			return compileProjectWithCache(builder, options)
+		})
 		if err != nil {
 			return "", err
 		}
 	}
 
+	if options.DryRun {
+		return options.CompiledBinary, nil
+	}
+
+	options.Timings.record(PhaseCompile, compileStart)
+	options.reportProgress(PhaseCompile, "Application compiled", 80)
 	outputLogger.Println("Done.")
 
+	if options.isSharedLibrary() {
+		options.addArtifact(sharedLibraryHeader(options.CompiledBinary, options.Platform))
+	}
+
+	if options.VerifyBinary {
+		outputLogger.Print("  - Verifying application: ")
+		err := verifyBinary(options.CompiledBinary, options.Platform, options.Arch)
+		if err != nil {
+			return "", err
+		}
+		outputLogger.Println("Done.")
+	}
+
+	hookArgs["${bin}"] = options.CompiledBinary
+	for _, hook := range hookIdentifiers(options) {
+		if err := execPostCompileHook(outputLogger, options, hook, hookArgs); err != nil {
+			return "", err
+		}
+	}
+
 	// Do we need to pack the app for non-windows?
-	if options.Pack && options.Platform != "windows" {
+	// Servers are not bundled as desktop applications
+	if options.Pack && options.Platform != "windows" && options.OutputType != "server" && !options.isSharedLibrary() {
 
+		options.phase = PhasePackaging
 		outputLogger.Print("  - Packaging application: ")
 
-		// TODO: Allow cross platform build
-		err := packageProject(options, runtime.GOOS)
+		packagingStart := time.Now()
+		err := packageProject(options, options.Platform)
 		if err != nil {
-			return "", err
+			return "", &PackagingError{Err: err}
 		}
+		options.Timings.record(PhasePackaging, packagingStart)
+		options.reportProgress(PhasePackaging, "Application packaged", 95)
 		outputLogger.Println("Done.")
+
+		if options.Platform == "darwin" {
+			err := signMacBundle(options)
+			if err != nil {
+				return "", &PackagingError{Err: err}
+			}
+			if options.ZipBundle {
+				outputLogger.Print("  - Zipping application: ")
+				if err := zipMacBundle(options); err != nil {
+					return "", &PackagingError{Err: err}
+				}
+				outputLogger.Println("Done.")
+			}
+		}
 	}
 
-	if options.Platform == "windows" {
+	// Notices are only useful when someone is watching the build
+	showNotices := !options.SuppressNotices && options.Verbosity >= 1
+	if showNotices && options.Platform == "windows" && options.OutputType != "server" && !options.isSharedLibrary() {
 		const expWebView2Loader = "exp_gowebview2loader"
 
 		message := ""
@@ -319,12 +1066,75 @@ func execBuildApplication(builder Builder, options *Options) (string, error) {
 			tags = append(tags, expWebView2Loader)
 			message = fmt.Sprintf("An experimental Go native WebView2Loader is available. We would love to hear your feedback about it and invite you to test it by building with `-tags %s`", strings.Join(tags, ","))
 		}
-		println(colour.Green("  - " + message))
+		outputLogger.Println("%s", colour.Green("  - "+message))
+	}
+
+	return options.CompiledBinary, nil
+}
+
+// execMultiArchBuild builds the application once per given arch. The bin directory is only
+// cleaned before the first arch. All the compiled binaries are recorded in CompiledBinaries
+// and CompiledBinary is set to the binary of the first arch.
+func execMultiArchBuild(builder Builder, options *Options, archs []string) (string, error) {
+	// Work out all the filenames up front as compiling updates the project output filename
+	outputFiles := make([]string, len(archs))
+	for index, arch := range archs {
+		archs[index] = strings.TrimSpace(arch)
+		if archs[index] == "universal" {
+			return "", fmt.Errorf("arch 'universal' cannot be combined with other architectures")
+		}
+		outputFiles[index] = multiArchOutputFilename(builder, options, archs[index])
+	}
+
+	options.CompiledBinaries = map[string]string{}
+	for index, arch := range archs {
+		targetOptions := options.cloneForTarget(arch, outputFiles[index])
+		targetOptions.CleanBinDirectory = options.CleanBinDirectory && index == 0
+		if options.Pack && options.Platform == "darwin" {
+			targetOptions.bundleSuffix = "-" + arch
+		}
+
+		options.Logger.Println("  - Target arch: %s", arch)
+		compiledBinary, err := execBuildApplication(builder, targetOptions)
+		if err != nil {
+			return "", err
+		}
+		options.CompiledBinaries[arch] = compiledBinary
+		options.GeneratedArtifacts = append(options.GeneratedArtifacts, targetOptions.GeneratedArtifacts...)
+		if index == 0 {
+			options.CompiledBinary = compiledBinary
+		}
 	}
 
 	return options.CompiledBinary, nil
 }
 
+// multiArchOutputFilename returns the output filename of the given arch in a multi-arch build.
+// EG: app-linux-amd64 or app-amd64.exe
+func multiArchOutputFilename(builder Builder, options *Options, arch string) string {
+	if options.isSharedLibrary() && options.OutputFile != "" {
+		outputFile := strings.TrimSuffix(options.OutputFile, sharedLibraryExtension(options.Platform))
+		return sharedLibraryFilename(fmt.Sprintf("%s-%s", outputFile, arch), options.Platform)
+	}
+	if options.OutputFile == "" {
+		targetOptions := options.cloneForTarget(arch, "")
+		outputFile := builder.OutputFilename(targetOptions)
+		if strings.Contains(options.OutputNameTemplate, "{arch}") {
+			return outputFile
+		}
+		if options.Platform == "windows" && options.isSharedLibrary() {
+			outputFile = strings.TrimSuffix(outputFile, ".dll") + "-" + arch + ".dll"
+		} else if options.Platform == "windows" {
+			outputFile = strings.TrimSuffix(outputFile, ".exe") + "-" + arch + ".exe"
+		}
+		return outputFile
+	}
+	if options.Platform == "windows" {
+		return strings.TrimSuffix(options.OutputFile, ".exe") + "-" + arch + ".exe"
+	}
+	return fmt.Sprintf("%s-%s-%s", options.OutputFile, options.Platform, arch)
+}
+
 func execPreBuildHook(outputLogger *clilogger.CLILogger, options *Options, hookIdentifier string, argReplacements map[string]string) error {
 	preBuildHook := options.ProjectData.PreBuildHooks[hookIdentifier]
 	if preBuildHook == "" {
@@ -344,7 +1154,209 @@ func execPostBuildHook(outputLogger *clilogger.CLILogger, options *Options, hook
 
 }
 
+func execPreCompileHook(outputLogger *clilogger.CLILogger, options *Options, hookIdentifier string, argReplacements map[string]string) error {
+	preCompileHook := options.ProjectData.PreCompileHooks[hookIdentifier]
+	if preCompileHook == "" {
+		return nil
+	}
+
+	return executeBuildHook(outputLogger, options, hookIdentifier, argReplacements, preCompileHook, "pre-compile")
+}
+
+func execPostCompileHook(outputLogger *clilogger.CLILogger, options *Options, hookIdentifier string, argReplacements map[string]string) error {
+	postCompileHook := options.ProjectData.PostCompileHooks[hookIdentifier]
+	if postCompileHook == "" {
+		return nil
+	}
+
+	return executeBuildHook(outputLogger, options, hookIdentifier, argReplacements, postCompileHook, "post-compile")
+}
+
+// hookOutput captures the output of a build hook. Both streams are kept on their own and
+// interleaved, as they were written, in combined, with each line labelled with its stream.
+type hookOutput struct {
+	mu             sync.Mutex
+	combined       bytes.Buffer
+	stdout, stderr hookStream
+	last           *hookStream
+}
+
+// hookStream is the writer for one stream of a hookOutput
+type hookStream struct {
+	output *hookOutput
+	label  string
+	text   bytes.Buffer
+}
+
+func newHookOutput() *hookOutput {
+	result := &hookOutput{}
+	result.stdout = hookStream{output: result, label: "stdout"}
+	result.stderr = hookStream{output: result, label: "stderr"}
+	return result
+}
+
+func (s *hookStream) Write(p []byte) (int, error) {
+	s.output.mu.Lock()
+	defer s.output.mu.Unlock()
+
+	s.text.Write(p)
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		// Start a new labelled line, unless this stream is continuing its own line
+		if s.output.last != s {
+			if s.output.last != nil {
+				s.output.combined.WriteString("\n")
+			}
+			s.output.combined.WriteString("[" + s.label + "] ")
+		}
+		s.output.combined.Write(line)
+		s.output.last = s
+		if line[len(line)-1] == '\n' {
+			s.output.last = nil
+		}
+	}
+	return len(p), nil
+}
+
+// Combined returns the output of both streams, in the order it was written
+func (o *hookOutput) Combined() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.last != nil {
+		return o.combined.String() + "\n"
+	}
+	return o.combined.String()
+}
+
+// runHookCommand runs the given build hook command in the given directory, capturing its output
+func runHookCommand(ctx context.Context, directory string, args []string) (*hookOutput, error) {
+	output := newHookOutput()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = directory
+	cmd.Stdout = &output.stdout
+	cmd.Stderr = &output.stderr
+	err := cmd.Run()
+	return output, err
+}
+
+// appendHookOutput appends the combined output of a build hook to the given file
+func appendHookOutput(filename string, hookName string, hookIdentifier string, args []string, output *hookOutput) error {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "[%s] %s build hook '%s': %s\n%s\n", time.Now().Format(time.RFC3339), hookName, hookIdentifier, strings.Join(args, " "), output.Combined())
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+var hookTokenRegex = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// expandHookEnvironment replaces ${ENV_NAME} tokens in the build hook with the value of the
+// environment variable. Tokens in argReplacements, EG: ${platform}, are left as they are.
+// An error is returned for tokens that are neither.
+func expandHookEnvironment(buildHook string, argReplacements map[string]string) (string, error) {
+	var unknown []string
+	result := hookTokenRegex.ReplaceAllStringFunc(buildHook, func(token string) string {
+		if _, ok := argReplacements[token]; ok {
+			return token
+		}
+		value, ok := os.LookupEnv(hookTokenRegex.FindStringSubmatch(token)[1])
+		if !ok {
+			unknown = append(unknown, token)
+			return token
+		}
+		return value
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown token(s) %s: not a build hook token or environment variable", strings.Join(unknown, ", "))
+	}
+	return result, nil
+}
+
+// splitHookCommand splits a build hook command into its arguments using shell style quoting.
+// Single and double quotes group words and a backslash escapes a following space or quote.
+// Any other backslash is kept as is so that Windows paths work unquoted.
+func splitHookCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes) && quote != '\'' && strings.ContainsRune(" \t\"'", runes[i+1]):
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in command: %s", quote, command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// hookIdentifiers returns the hook keys for the current target in the order they are executed
+func hookIdentifiers(options *Options) []string {
+	return []string{options.Platform + "/" + options.Arch, options.Platform + "/*", "*/*"}
+}
+
+// hookArguments returns the tokens replaced in the hook commands of this build:
+//   - ${platform}: the GOOS/GOARCH being built
+//   - ${arch}: the GOARCH being built
+//   - ${mode}: the build mode, dev, production or debug
+//   - ${outputType}: the output type, EG: desktop or server
+//   - ${name}: the project name
+//
+// ${bin} is added once the application is compiled and ${phase} for the OnBuildFailed hook
+func hookArguments(options *Options) map[string]string {
+	return map[string]string{
+		"${platform}":   options.Platform + "/" + options.Arch,
+		"${arch}":       options.Arch,
+		"${mode}":       options.Mode.String(),
+		"${outputType}": options.OutputType,
+		"${name}":       options.ProjectData.Name,
+	}
+}
+
+// executeBuildHook runs the given build hook. Failures are returned as a HookError
 func executeBuildHook(outputLogger *clilogger.CLILogger, options *Options, hookIdentifier string, argReplacements map[string]string, buildHook string, hookName string) error {
+	if err := runBuildHook(outputLogger, options, hookIdentifier, argReplacements, buildHook, hookName); err != nil {
+		return &HookError{Identifier: hookIdentifier, Phase: hookName, Err: err}
+	}
+	return nil
+}
+
+func runBuildHook(outputLogger *clilogger.CLILogger, options *Options, hookIdentifier string, argReplacements map[string]string, buildHook string, hookName string) error {
 	if !options.ProjectData.RunNonNativeBuildHooks {
 		if hookIdentifier == "" {
 			// That's the global hook
@@ -362,8 +1374,22 @@ func executeBuildHook(outputLogger *clilogger.CLILogger, options *Options, hookI
 		}
 	}
 
-	outputLogger.Print("  - Executing %s build hook '%s': ", hookName, hookIdentifier)
-	args := strings.Split(buildHook, " ")
+	if hookIdentifier == "" {
+		outputLogger.Print("  - Executing %s build hook: ", hookName)
+	} else {
+		outputLogger.Print("  - Executing %s build hook '%s': ", hookName, hookIdentifier)
+	}
+	buildHook, err := expandHookEnvironment(buildHook, argReplacements)
+	if err != nil {
+		return fmt.Errorf("build hook '%s': %w", hookIdentifier, err)
+	}
+	args, err := splitHookCommand(buildHook)
+	if err != nil {
+		return fmt.Errorf("build hook '%s': %w", hookIdentifier, err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("build hook '%s' has no command", hookIdentifier)
+	}
 	for i, arg := range args {
 		newArg := argReplacements[arg]
 		if newArg == "" {
@@ -372,16 +1398,34 @@ func executeBuildHook(outputLogger *clilogger.CLILogger, options *Options, hookI
 		args[i] = newArg
 	}
 
-	if options.Verbosity == VERBOSE {
+	if options.verbosity() == VERBOSE {
 		outputLogger.Println("%s", strings.Join(args, " "))
 	}
 
-	stdout, stderr, err := shell.RunCommand(options.BinDirectory, args[0], args[1:]...)
-	if options.Verbosity == VERBOSE {
-		println(stdout)
+	ctx := options.buildContext()
+	if options.HookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.HookTimeout)
+		defer cancel()
+	}
+
+	output, err := runHookCommand(ctx, options.BinDirectory, args)
+	if options.verbosity() == VERBOSE {
+		outputLogger.Println("%s", output.stdout.text.String())
+	}
+	if options.HookOutputFile != "" {
+		if err := appendHookOutput(options.HookOutputFile, hookName, hookIdentifier, args, output); err != nil {
+			return err
+		}
+	}
+	if err := options.buildContext().Err(); err != nil {
+		return err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("build hook '%s' timed out after %s", hookIdentifier, options.HookTimeout)
 	}
 	if err != nil {
-		return fmt.Errorf("%s - %s", err.Error(), stderr)
+		return fmt.Errorf("%s - %s", err.Error(), output.stderr.text.String())
 	}
 	outputLogger.Println("Done.")
 
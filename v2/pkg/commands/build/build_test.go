@@ -0,0 +1,2492 @@
+package build
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/project"
+	"github.com/wailsapp/wails/v2/internal/staticanalysis"
+	"github.com/wailsapp/wails/v2/pkg/clilogger"
+)
+
+func Test_splitHookCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "simple",
+			input: "echo one two",
+			want:  []string{"echo", "one", "two"},
+		},
+		{
+			name:  "repeated spaces",
+			input: "echo  one   two",
+			want:  []string{"echo", "one", "two"},
+		},
+		{
+			name:  "double quoted windows path",
+			input: `"C:\Program Files\tool.exe" sign ${bin}`,
+			want:  []string{`C:\Program Files\tool.exe`, "sign", "${bin}"},
+		},
+		{
+			name:  "single quoted path",
+			input: `/usr/bin/tool '/tmp/my dir/file'`,
+			want:  []string{"/usr/bin/tool", "/tmp/my dir/file"},
+		},
+		{
+			name:  "unquoted windows path",
+			input: `C:\tools\tool.exe ${bin}`,
+			want:  []string{`C:\tools\tool.exe`, "${bin}"},
+		},
+		{
+			name:  "escaped spaces",
+			input: `/usr/bin/tool /tmp/my\ dir/file`,
+			want:  []string{"/usr/bin/tool", "/tmp/my dir/file"},
+		},
+		{
+			name:  "embedded quotes",
+			input: `echo "say \"hello\"" 'it"s'`,
+			want:  []string{"echo", `say "hello"`, `it"s`},
+		},
+		{
+			name:  "quotes within an argument",
+			input: `tool --name="my app"`,
+			want:  []string{"tool", "--name=my app"},
+		},
+		{
+			name:  "empty arguments",
+			input: `tool "" ''`,
+			want:  []string{"tool", "", ""},
+		},
+		{
+			name:    "unterminated quote",
+			input:   `tool "oops`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitHookCommand(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitHookCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitHookCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyBinary(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("test binary is only checked on linux")
+	}
+	testBinary, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyBinary(testBinary, "linux", runtime.GOARCH); err != nil {
+		t.Errorf("verifyBinary() error = %v", err)
+	}
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+	if err := verifyBinary(testBinary, "linux", otherArch); err == nil {
+		t.Errorf("verifyBinary() expected an error for arch %s", otherArch)
+	}
+	if err := verifyBinary(testBinary, "windows", runtime.GOARCH); err == nil {
+		t.Errorf("verifyBinary() expected an error for windows")
+	}
+}
+
+func Test_createEmbedDirectory(t *testing.T) {
+	tests := []struct {
+		name            string
+		existingFile    string
+		placeholderName string
+		wantPlaceholder bool
+	}{
+		{
+			name:            "missing directory",
+			placeholderName: ".gitkeep",
+			wantPlaceholder: true,
+		},
+		{
+			name:            "custom placeholder",
+			placeholderName: ".keep",
+			wantPlaceholder: true,
+		},
+		{
+			name:            "directory with files",
+			existingFile:    "index.html",
+			placeholderName: ".gitkeep",
+		},
+		{
+			name: "no placeholder",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedDir := filepath.Join(t.TempDir(), "frontend", "dist")
+			if tt.existingFile != "" {
+				if err := os.MkdirAll(embedDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(embedDir, tt.existingFile), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := createEmbedDirectory(embedDir, tt.placeholderName); err != nil {
+				t.Fatalf("createEmbedDirectory() error = %v", err)
+			}
+			entries, err := os.ReadDir(embedDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			var want []string
+			if tt.existingFile != "" {
+				want = append(want, tt.existingFile)
+			}
+			if tt.wantPlaceholder {
+				want = append(want, tt.placeholderName)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("createEmbedDirectory() created %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func Test_diffDirectories(t *testing.T) {
+	writeFiles := func(dir string, files map[string]string) {
+		for name, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	existingDir := filepath.Join(t.TempDir(), "existing")
+	generatedDir := filepath.Join(t.TempDir(), "generated")
+	writeFiles(existingDir, map[string]string{
+		"go/main/App.js":       "unchanged",
+		"go/main/App.d.ts":     "old",
+		"go/main/Removed.js":   "removed",
+		"runtime/runtime.js":   "runtime",
+		"runtime/package.json": "{}",
+	})
+	writeFiles(generatedDir, map[string]string{
+		"go/main/App.js":       "unchanged",
+		"go/main/App.d.ts":     "new",
+		"go/main/Added.js":     "added",
+		"runtime/runtime.js":   "runtime",
+		"runtime/package.json": "{}",
+	})
+
+	got, err := diffDirectories(existingDir, generatedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"go/main/Added.js", "go/main/App.d.ts", "go/main/Removed.js"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffDirectories() = %q, want %q", got, want)
+	}
+
+	got, err = diffDirectories(generatedDir, generatedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("diffDirectories() = %q, want no changes", got)
+	}
+
+	got, err = diffDirectories(filepath.Join(existingDir, "missing"), generatedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 {
+		t.Errorf("diffDirectories() = %q, want all generated files", got)
+	}
+}
+
+type failingFrontendBuilder struct {
+	*BaseBuilder
+	attempts int
+	failures int
+	err      func() error
+}
+
+func (f *failingFrontendBuilder) BuildFrontend(_ *clilogger.CLILogger) error {
+	f.attempts++
+	if f.attempts <= f.failures {
+		return f.err()
+	}
+	return nil
+}
+
+func Test_buildFrontendWithRetries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses the false command")
+	}
+	defer func(backoff time.Duration) { frontendRetryBackoff = backoff }(frontendRetryBackoff)
+	frontendRetryBackoff = time.Millisecond
+
+	exitError := func() error { return exec.Command("false").Run() }
+	otherError := func() error { return errors.New("frontend directory does not exist") }
+
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int
+		err          func() error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "no retries", failures: 1, err: exitError, wantAttempts: 1, wantErr: true},
+		{name: "succeeds on retry", retries: 2, failures: 2, err: exitError, wantAttempts: 3},
+		{name: "retries exhausted", retries: 1, failures: 5, err: exitError, wantAttempts: 2, wantErr: true},
+		{name: "not an exit error", retries: 3, failures: 1, err: otherError, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{
+				Logger:               clilogger.New(io.Discard),
+				FrontendBuildRetries: tt.retries,
+			}
+			builder := &failingFrontendBuilder{BaseBuilder: NewBaseBuilder(options), failures: tt.failures, err: tt.err}
+			err := buildFrontendWithRetries(builder, options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildFrontendWithRetries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if builder.attempts != tt.wantAttempts {
+				t.Errorf("buildFrontendWithRetries() made %d attempts, want %d", builder.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func Test_readWindowsManifest(t *testing.T) {
+	projectDir := t.TempDir()
+	valid := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<assembly manifestVersion="1.0" xmlns="urn:schemas-microsoft-com:asm.v1">
+    <trustInfo xmlns="urn:schemas-microsoft-com:asm.v3">
+        <security>
+            <requestedPrivileges>
+                <requestedExecutionLevel level="requireAdministrator" uiAccess="false"/>
+            </requestedPrivileges>
+        </security>
+    </trustInfo>
+</assembly>`
+	if err := os.WriteFile(filepath.Join(projectDir, "valid.manifest"), []byte(valid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "invalid.manifest"), []byte(`<assembly><trustInfo></assembly>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &Options{
+		ProjectData:         &project.Project{Path: projectDir},
+		WindowsManifestFile: "valid.manifest",
+	}
+	got, err := readWindowsManifest(options)
+	if err != nil {
+		t.Fatalf("readWindowsManifest() error = %v", err)
+	}
+	if string(got) != valid {
+		t.Errorf("readWindowsManifest() = %q, want %q", got, valid)
+	}
+
+	options.WindowsManifestFile = "invalid.manifest"
+	if _, err := readWindowsManifest(options); err == nil {
+		t.Errorf("readWindowsManifest() expected an error for malformed XML")
+	}
+}
+
+func Test_checkFreeDiskSpace(t *testing.T) {
+	binDirectory := filepath.Join(t.TempDir(), "build", "bin")
+
+	options := &Options{BinDirectory: binDirectory}
+	if err := checkFreeDiskSpace(options); err != nil {
+		t.Errorf("checkFreeDiskSpace() error = %v, want no check", err)
+	}
+
+	options.MinFreeDiskBytes = 1
+	if err := checkFreeDiskSpace(options); err != nil {
+		t.Errorf("checkFreeDiskSpace() error = %v", err)
+	}
+
+	options.MinFreeDiskBytes = math.MaxUint64
+	if err := checkFreeDiskSpace(options); err == nil {
+		t.Errorf("checkFreeDiskSpace() expected an error")
+	}
+}
+
+func Test_writeChecksums(t *testing.T) {
+	binDirectory := t.TempDir()
+	for filename, content := range map[string]string{"app-amd64": "amd64", "app-arm64": "arm64", "app.deb": "deb"} {
+		if err := os.WriteFile(filepath.Join(binDirectory, filename), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := &Options{
+		BinDirectory:       binDirectory,
+		CompiledBinary:     filepath.Join(binDirectory, "app-amd64"),
+		GeneratedArtifacts: []string{filepath.Join(binDirectory, "app.deb"), t.TempDir()},
+	}
+	if err := writeChecksums(options); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second target adds its entries to the same file
+	options = &Options{
+		BinDirectory:   binDirectory,
+		CompiledBinary: filepath.Join(binDirectory, "app-arm64"),
+	}
+	if err := writeChecksums(options); err != nil {
+		t.Fatal(err)
+	}
+
+	var want string
+	for _, filename := range []string{"app-amd64", "app-arm64", "app.deb"} {
+		hash, err := sha256File(filepath.Join(binDirectory, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want += hash + "  " + filename + "\n"
+	}
+	got, err := os.ReadFile(filepath.Join(binDirectory, checksumsFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("writeChecksums() wrote %q, want %q", got, want)
+	}
+}
+
+func Test_removeEmbedPlaceholder(t *testing.T) {
+	created := filepath.Join(t.TempDir(), "dist")
+	if err := createEmbedDirectory(created, ".gitkeep"); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeEmbedPlaceholder(created, ".gitkeep"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(created); len(entries) != 0 {
+		t.Errorf("expected the placeholder to be removed, got %v", entries)
+	}
+
+	// A placeholder with content or next to other files wasn't created by the build
+	for name, files := range map[string]map[string]string{
+		"content": {".gitkeep": "keep me"},
+		"others":  {".gitkeep": "", "index.html": ""},
+	} {
+		dir := filepath.Join(t.TempDir(), name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for filename, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := removeEmbedPlaceholder(dir, ".gitkeep"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".gitkeep")); err != nil {
+			t.Errorf("%s: expected the placeholder to be kept: %v", name, err)
+		}
+	}
+
+	if err := removeEmbedPlaceholder(filepath.Join(t.TempDir(), "missing"), ".gitkeep"); err != nil {
+		t.Errorf("removeEmbedPlaceholder() error = %v for a missing directory", err)
+	}
+}
+
+func Test_packageManagerCommand(t *testing.T) {
+	tests := []struct {
+		command        string
+		packageManager string
+		want           string
+	}{
+		{command: "npm install", packageManager: "npm", want: "npm install"},
+		{command: "npm install", packageManager: "pnpm", want: "pnpm install"},
+		{command: "npm run build", packageManager: "bun", want: "bun run build"},
+		{command: "npm ci", packageManager: "pnpm", want: "pnpm install --frozen-lockfile"},
+		{command: "yarn build", packageManager: "pnpm", want: "yarn build"},
+		{command: "", packageManager: "pnpm", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command+" "+tt.packageManager, func(t *testing.T) {
+			if got := packageManagerCommand(tt.command, tt.packageManager); got != tt.want {
+				t.Errorf("packageManagerCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_frontendPackageManager(t *testing.T) {
+	frontendDir := t.TempDir()
+	got, err := frontendPackageManager(&Options{}, frontendDir)
+	if err != nil || got != "npm" {
+		t.Errorf("frontendPackageManager() = %v, %v, want npm", got, err)
+	}
+
+	if _, err := frontendPackageManager(&Options{FrontendPackageManager: "deno"}, frontendDir); err == nil {
+		t.Errorf("frontendPackageManager() expected an error for an unsupported package manager")
+	}
+
+	if err := os.WriteFile(filepath.Join(frontendDir, "pnpm-lock.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exec.LookPath("pnpm"); err != nil {
+		if _, err := frontendPackageManager(&Options{}, frontendDir); err == nil {
+			t.Errorf("frontendPackageManager() expected an error as pnpm is not installed")
+		}
+		return
+	}
+	got, err = frontendPackageManager(&Options{}, frontendDir)
+	if err != nil || got != "pnpm" {
+		t.Errorf("frontendPackageManager() = %v, %v, want pnpm", got, err)
+	}
+}
+
+func Test_verifyModTidy(t *testing.T) {
+	projectDir := t.TempDir()
+	tidy := "module example.com/app\n\ngo 1.18\n"
+	if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(tidy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &Options{
+		Compiler:    "go",
+		ModTidyMode: ModTidyVerify,
+		ProjectData: &project.Project{Path: projectDir},
+	}
+	if err := runModTidy(options); err != nil {
+		t.Errorf("runModTidy() error = %v for a tidy module", err)
+	}
+
+	// The unused requirement would be removed by go mod tidy
+	untidy := tidy + "\nrequire github.com/pkg/errors v0.9.1\n"
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(untidy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runModTidy(options); err == nil {
+		t.Errorf("runModTidy() expected an error for an untidy module")
+	}
+	got, err := os.ReadFile(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != untidy {
+		t.Errorf("runModTidy() modified go.mod: %q", got)
+	}
+
+	// Running tidy must tidy the project, not the working directory
+	options.ModTidyMode = ModTidyRun
+	if err := runModTidy(options); err != nil {
+		t.Fatalf("runModTidy() error = %v", err)
+	}
+	got, err = os.ReadFile(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != tidy {
+		t.Errorf("runModTidy() did not tidy the project go.mod: %q", got)
+	}
+}
+
+func Test_resolveSourceDate(t *testing.T) {
+	options := &Options{ProjectData: &project.Project{Path: t.TempDir()}}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	got, err := resolveSourceDate(options)
+	if err != nil {
+		t.Fatalf("resolveSourceDate() error = %v", err)
+	}
+	if want := time.Unix(1700000000, 0).UTC(); !got.Equal(want) {
+		t.Errorf("resolveSourceDate() = %v, want %v", got, want)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := resolveSourceDate(options); err == nil {
+		t.Errorf("resolveSourceDate() expected an error for an invalid SOURCE_DATE_EPOCH")
+	}
+
+	// Not a git repository
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	if _, err := resolveSourceDate(options); err == nil {
+		t.Errorf("resolveSourceDate() expected an error outside a git repository")
+	}
+}
+
+func Test_validateIconFile(t *testing.T) {
+	dir := t.TempDir()
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"icon.png":   pngData.Bytes(),
+		"icon.ico":   {0, 0, 1, 0, 1, 0},
+		"icon.icns":  []byte("icns\x00\x00\x00\x08"),
+		"broken.png": []byte("not a png"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		platform string
+		wantErr  bool
+	}{
+		{name: "icon.png", platform: "linux"},
+		{name: "icon.png", platform: "windows"},
+		{name: "icon.ico", platform: "windows"},
+		{name: "icon.icns", platform: "darwin"},
+		{name: "icon.ico", platform: "linux", wantErr: true},
+		{name: "icon.icns", platform: "windows", wantErr: true},
+		{name: "broken.png", platform: "darwin", wantErr: true},
+		{name: "missing.png", platform: "linux", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform+"/"+tt.name, func(t *testing.T) {
+			if err := validateIconFile(filepath.Join(dir, tt.name), tt.platform); (err != nil) != tt.wantErr {
+				t.Errorf("validateIconFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_reportBinarySize(t *testing.T) {
+	projectDir := t.TempDir()
+	binary := filepath.Join(projectDir, "app")
+	if err := os.WriteFile(binary, make([]byte, 2048), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	options := &Options{
+		Logger:         clilogger.New(&output),
+		ProjectData:    &project.Project{Path: projectDir, BuildDir: "build"},
+		Platform:       "linux",
+		Arch:           "amd64",
+		CompiledBinary: binary,
+	}
+	if err := reportBinarySize(options); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  - Binary size: 2 KB\n"; output.String() != want {
+		t.Errorf("reportBinarySize() printed %q, want %q", output.String(), want)
+	}
+
+	if err := os.WriteFile(binary, make([]byte, 5*1024), 0755); err != nil {
+		t.Fatal(err)
+	}
+	output.Reset()
+	if err := reportBinarySize(options); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  - Binary size: 5 KB (+3 KB since last build)\n"; output.String() != want {
+		t.Errorf("reportBinarySize() printed %q, want %q", output.String(), want)
+	}
+}
+
+func Test_executeBuildHookError(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false not found on PATH")
+	}
+	options := &Options{
+		Logger:       clilogger.New(io.Discard),
+		ProjectData:  &project.Project{},
+		BinDirectory: t.TempDir(),
+	}
+	err := executeBuildHook(options.Logger, options, "*/*", nil, "false", "post")
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("executeBuildHook() error = %v, want a HookError", err)
+	}
+	if hookErr.Identifier != "*/*" || hookErr.Phase != "post" {
+		t.Errorf("executeBuildHook() HookError = %+v, want the identifier */* and phase post", hookErr)
+	}
+	if hookErr.Error() != hookErr.Err.Error() {
+		t.Errorf("HookError.Error() = %q, want the wrapped message %q", hookErr.Error(), hookErr.Err.Error())
+	}
+}
+
+func Test_hookOutputCombined(t *testing.T) {
+	output := newHookOutput()
+	_, _ = output.stdout.Write([]byte("building"))
+	_, _ = output.stderr.Write([]byte("warning: one\nwarning: two\n"))
+	_, _ = output.stdout.Write([]byte(" done\nnext"))
+	_, _ = output.stdout.Write([]byte(" step\n"))
+	_, _ = output.stderr.Write([]byte("no newline"))
+
+	want := "[stdout] building\n[stderr] warning: one\n[stderr] warning: two\n[stdout]  done\n[stdout] next step\n[stderr] no newline\n"
+	if got := output.Combined(); got != want {
+		t.Errorf("Combined() = %q, want %q", got, want)
+	}
+	if got := output.stdout.text.String(); got != "building done\nnext step\n" {
+		t.Errorf("stdout = %q, want the unlabelled stdout", got)
+	}
+	if got := output.stderr.text.String(); got != "warning: one\nwarning: two\nno newline" {
+		t.Errorf("stderr = %q, want the unlabelled stderr", got)
+	}
+}
+
+func Test_executeBuildHookOutputFile(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found on PATH")
+	}
+	var logged bytes.Buffer
+	hookOutputFile := filepath.Join(t.TempDir(), "hooks.log")
+	options := &Options{
+		Logger:         clilogger.New(&logged),
+		ProjectData:    &project.Project{},
+		BinDirectory:   t.TempDir(),
+		HookOutputFile: hookOutputFile,
+		Verbosity:      VERBOSE,
+	}
+	err := executeBuildHook(options.Logger, options, "*/*", nil, `sh -c "echo out1; echo err1 >&2; echo out2"`, "post")
+	if err != nil {
+		t.Fatalf("executeBuildHook() error = %v", err)
+	}
+
+	contents, err := os.ReadFile(hookOutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"post build hook '*/*': sh -c", "[stdout] out1\n", "[stderr] err1\n", "[stdout] out2\n"} {
+		if !strings.Contains(string(contents), want) {
+			t.Errorf("hook output file has no %q:\n%s", want, contents)
+		}
+	}
+	if !strings.Contains(logged.String(), "out1\nout2\n") {
+		t.Errorf("verbose hook stdout was not logged:\n%s", logged.String())
+	}
+}
+
+func Test_packageApplicationForDarwinBundleNames(t *testing.T) {
+	projectDir := t.TempDir()
+	projectData := &project.Project{
+		Name:     "myapp",
+		Path:     projectDir,
+		BuildDir: filepath.Join(projectDir, "build"),
+		Info:     project.Info{ProductName: "My App"},
+	}
+	binDirectory := filepath.Join(projectDir, "build", "bin")
+	if err := os.MkdirAll(binDirectory, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bundleName := range []string{"My App Beta", "My App & Friends.app"} {
+		compiledBinary := filepath.Join(binDirectory, "myapp")
+		if err := os.WriteFile(compiledBinary, []byte("binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		options := &Options{
+			ProjectData:    projectData,
+			BinDirectory:   binDirectory,
+			CompiledBinary: compiledBinary,
+			BundleName:     bundleName,
+			Mode:           Production,
+		}
+		if err := packageApplicationForDarwin(options); err != nil {
+			t.Fatalf("packageApplicationForDarwin(%s) error = %v", bundleName, err)
+		}
+
+		name := strings.TrimSuffix(bundleName, ".app")
+		wantBundle := filepath.Join(binDirectory, name+".app")
+		if options.CompiledBundle != wantBundle {
+			t.Errorf("CompiledBundle = %s, want %s", options.CompiledBundle, wantBundle)
+		}
+		if want := filepath.Join(wantBundle, "Contents", "MacOS", "myapp"); options.CompiledBinary != want {
+			t.Errorf("CompiledBinary = %s, want the binary name to be unchanged: %s", options.CompiledBinary, want)
+		}
+		plist, err := os.ReadFile(filepath.Join(wantBundle, "Contents", "Info.plist"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		escapedName := strings.ReplaceAll(name, "&", "&amp;")
+		for _, key := range []string{"CFBundleName", "CFBundleDisplayName"} {
+			if want := "<key>" + key + "</key>\n        <string>" + escapedName + "</string>"; !strings.Contains(string(plist), want) {
+				t.Errorf("Info.plist of %s has no %s %q:\n%s", bundleName, key, escapedName, plist)
+			}
+		}
+		if !strings.Contains(string(plist), "<string>myapp</string>") {
+			t.Errorf("Info.plist of %s should keep the executable name myapp:\n%s", bundleName, plist)
+		}
+	}
+
+	// The first variant must not have been changed by the second
+	plist, err := os.ReadFile(filepath.Join(binDirectory, "My App Beta.app", "Contents", "Info.plist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(plist), "Friends") {
+		t.Errorf("the first bundle's Info.plist has the second bundle's name:\n%s", plist)
+	}
+	if projectData.Name != "myapp" || projectData.Info.ProductName != "My App" {
+		t.Errorf("the project data was changed: %+v", projectData)
+	}
+
+	// Building both mac targets gives each arch its own bundle directory, leaving the bundle name alone
+	for _, arch := range []string{"arm64", "amd64"} {
+		compiledBinary := filepath.Join(binDirectory, "myapp")
+		if err := os.WriteFile(compiledBinary, []byte("binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		options := &Options{
+			ProjectData:      projectData,
+			BinDirectory:     binDirectory,
+			CompiledBinary:   compiledBinary,
+			Arch:             arch,
+			BundleArchSuffix: true,
+			Mode:             Production,
+		}
+		if err := packageApplicationForDarwin(options); err != nil {
+			t.Fatalf("packageApplicationForDarwin(%s) error = %v", arch, err)
+		}
+
+		wantBundle := filepath.Join(binDirectory, "myapp-"+arch+".app")
+		if options.CompiledBundle != wantBundle {
+			t.Errorf("CompiledBundle = %s, want %s", options.CompiledBundle, wantBundle)
+		}
+		plist, err := os.ReadFile(filepath.Join(wantBundle, "Contents", "Info.plist"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(plist), "myapp-"+arch) {
+			t.Errorf("Info.plist of the %s bundle should not have the arch in its names:\n%s", arch, plist)
+		}
+	}
+}
+
+func Test_mergePListExtras(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+    <dict>
+        <key>CFBundleName</key>
+        <string>myapp</string>
+        <key>NSHighResolutionCapable</key>
+        <string>true</string>
+    </dict>
+</plist>`
+	tests := []struct {
+		name    string
+		extras  PlistExtras
+		want    []string
+		notWant []string
+		wantErr bool
+	}{
+		{
+			name:   "adds keys",
+			extras: PlistExtras{"NSCameraUsageDescription": "To scan QR codes", "LSUIElement": true},
+			want: []string{
+				"<key>CFBundleName</key>\n        <string>myapp</string>",
+				"<key>LSUIElement</key>\n        <true/>",
+				"<key>NSCameraUsageDescription</key>\n        <string>To scan QR codes</string>",
+			},
+		},
+		{
+			name:    "replaces existing keys",
+			extras:  PlistExtras{"NSHighResolutionCapable": false},
+			want:    []string{"<key>NSHighResolutionCapable</key>\n        <false/>"},
+			notWant: []string{"<string>true</string>"},
+		},
+		{
+			name:   "encodes nested values",
+			extras: PlistExtras{"CFBundleURLTypes": []interface{}{map[string]interface{}{"CFBundleURLSchemes": []interface{}{"myapp"}, "Priority": float64(2)}}},
+			want: []string{
+				"<key>CFBundleURLTypes</key>\n        <array>\n            <dict>\n                <key>CFBundleURLSchemes</key>\n                <array>\n                    <string>myapp</string>\n                </array>\n",
+				"<key>Priority</key>\n                <integer>2</integer>",
+			},
+		},
+		{
+			name:   "escapes strings",
+			extras: PlistExtras{"NSMicrophoneUsageDescription": "Calls & <notes>"},
+			want:   []string{"<string>Calls &amp; &lt;notes&gt;</string>"},
+		},
+		{
+			name:    "unsupported type",
+			extras:  PlistExtras{"Data": []byte("data")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergePListExtras([]byte(plist), tt.extras)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergePListExtras() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, _, err := parsePList(got); err != nil {
+				t.Fatalf("merged Info.plist does not parse: %v\n%s", err, got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("merged Info.plist has no %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(string(got), notWant) {
+					t.Errorf("merged Info.plist should not have %q:\n%s", notWant, got)
+				}
+			}
+			if !strings.HasSuffix(string(got), "    </dict>\n</plist>") {
+				t.Errorf("merged Info.plist should end with the top level dict:\n%s", got)
+			}
+		})
+	}
+}
+
+func Test_packageTarballFileModes(t *testing.T) {
+	projectDir := t.TempDir()
+	binDirectory := filepath.Join(projectDir, "build", "bin")
+	if err := os.MkdirAll(binDirectory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	compiledBinary := filepath.Join(binDirectory, "myapp")
+	// A binary written under a restrictive umask
+	if err := os.WriteFile(compiledBinary, []byte("binary"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		fileModes map[string]string
+		want      map[string]int64
+	}{
+		{
+			name: "defaults",
+			want: map[string]int64{"myapp/myapp": 0755, "myapp/myapp.desktop": 0644, "myapp/myapp.png": 0644},
+		},
+		{
+			name:      "per-path modes",
+			fileModes: map[string]string{"*.desktop": "0600", "myapp.png": "0640"},
+			want:      map[string]int64{"myapp/myapp": 0755, "myapp/myapp.desktop": 0600, "myapp/myapp.png": 0640},
+		},
+		{
+			name:      "binary is always executable",
+			fileModes: map[string]string{"myapp": "0700"},
+			want:      map[string]int64{"myapp/myapp": 0711, "myapp/myapp.desktop": 0644, "myapp/myapp.png": 0644},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{
+				ProjectData: &project.Project{
+					Name:     "myapp",
+					Path:     projectDir,
+					BuildDir: filepath.Join(projectDir, "build"),
+				},
+				Platform:       "linux",
+				Arch:           "amd64",
+				BinDirectory:   binDirectory,
+				CompiledBinary: compiledBinary,
+				LinuxFileModes: tt.fileModes,
+			}
+			if err := packageTarball(options); err != nil {
+				t.Fatalf("packageTarball() error = %v", err)
+			}
+
+			archive, err := os.Open(options.CompiledBundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer archive.Close()
+			gzipReader, err := gzip.NewReader(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]int64{}
+			tarReader := tar.NewReader(gzipReader)
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[header.Name] = header.Mode
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tarball modes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_removeBinDirectoryBackups(t *testing.T) {
+	buildDir := t.TempDir()
+	binDirectory := filepath.Join(buildDir, "bin")
+	options := &Options{
+		BinDirectory:       binDirectory,
+		BackupBeforeClean:  true,
+		CleanBackupsToKeep: 2,
+	}
+	for i := 0; i < 3; i++ {
+		if err := os.MkdirAll(binDirectory, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(binDirectory, "myapp"), []byte{byte(i)}, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := removeBinDirectory(options); err != nil {
+			t.Fatalf("removeBinDirectory() error = %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := os.Stat(binDirectory); !os.IsNotExist(err) {
+		t.Errorf("bin directory should have been moved, stat error = %v", err)
+	}
+	backups, err := filepath.Glob(filepath.Join(buildDir, "bin.bak-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want the 2 newest: %v", len(backups), backups)
+	}
+	for i, backup := range backups {
+		content, err := os.ReadFile(filepath.Join(backup, "myapp"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []byte{byte(i + 1)}; !bytes.Equal(content, want) {
+			t.Errorf("backup %s has %v, want %v", backup, content, want)
+		}
+	}
+}
+
+func Test_runBuildFailedHook(t *testing.T) {
+	if _, err := exec.LookPath("touch"); err != nil {
+		t.Skip("touch not found on PATH")
+	}
+	tests := []struct {
+		name      string
+		phase     string
+		err       error
+		wantPhase string
+	}{
+		{name: "tracked phase", phase: PhaseFrontend, err: &FrontendBuildError{Err: errors.New("npm failed")}, wantPhase: "frontend"},
+		{name: "packaging", phase: PhaseCompile, err: &PackagingError{Err: errors.New("no icon")}, wantPhase: "packaging"},
+		{name: "hook", phase: PhaseCompile, err: &HookError{Identifier: "*/*", Phase: "pre-compile", Err: errors.New("exit status 1")}, wantPhase: "pre-compile hook"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binDirectory := t.TempDir()
+			options := &Options{
+				Logger:       clilogger.New(io.Discard),
+				ProjectData:  &project.Project{OnBuildFailed: "touch ${phase}"},
+				BinDirectory: binDirectory,
+				phase:        tt.phase,
+			}
+			runBuildFailedHook(options, tt.err)
+			if _, err := os.Stat(filepath.Join(binDirectory, tt.wantPhase)); err != nil {
+				t.Errorf("runBuildFailedHook() should run the hook with the phase %q: %v", tt.wantPhase, err)
+			}
+		})
+	}
+}
+
+func Test_writeBuildResult(t *testing.T) {
+	options := &Options{
+		Platform:       "darwin",
+		Arch:           "universal",
+		CompiledBundle: "/project/build/bin/My App.app",
+	}
+	var output bytes.Buffer
+	if err := writeBuildResult(&output, options, `/project/build/bin/My App.app/Contents/MacOS/my"app`); err != nil {
+		t.Fatal(err)
+	}
+	want := `WAILS_BUILD_RESULT={"binary":"/project/build/bin/My App.app/Contents/MacOS/my\"app","bundle":"/project/build/bin/My App.app","platform":"darwin","arch":"universal"}` + "\n"
+	if output.String() != want {
+		t.Errorf("writeBuildResult() = %q, want %q", output.String(), want)
+	}
+}
+
+func Test_workingDirBinDirectory(t *testing.T) {
+	workingDir := t.TempDir()
+	options := &Options{
+		ProjectData:  &project.Project{Path: t.TempDir()},
+		WorkingDir:   workingDir,
+		BinDirectory: "out",
+	}
+	cwd, err := options.workingDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolveBinDirectory(options, cwd)
+	if want := filepath.Join(workingDir, "out"); options.BinDirectory != want {
+		t.Errorf("BinDirectory = %s, want it relative to the working dir: %s", options.BinDirectory, want)
+	}
+}
+
+func Test_BuildConcurrently(t *testing.T) {
+	projectDir := t.TempDir()
+	projectData := &project.Project{
+		Name:           "myapp",
+		Path:           projectDir,
+		BuildDir:       filepath.Join(projectDir, "build"),
+		OutputFilename: "myapp",
+		OutputType:     "desktop",
+	}
+	targets := []struct {
+		platform string
+		arch     string
+		want     string
+	}{
+		{platform: "linux", arch: "amd64", want: "myapp-linux-amd64"},
+		{platform: "windows", arch: "arm64", want: "myapp.exe"},
+	}
+
+	results := make([]*Options, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		results[i] = &Options{
+			Logger:      clilogger.New(io.Discard),
+			ProjectData: projectData,
+			Platform:    target.platform,
+			Arch:        target.arch,
+			OutputType:  "desktop",
+			Mode:        Dev,
+			DryRun:      true,
+			UserTags:    []string{"shared"}[:1:1],
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = Build(results[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, target := range targets {
+		if errs[i] != nil {
+			t.Fatalf("Build(%s/%s) error = %v", target.platform, target.arch, errs[i])
+		}
+		if got := filepath.Base(results[i].CompiledBinary); got != target.want {
+			t.Errorf("Build(%s/%s) CompiledBinary = %s, want %s", target.platform, target.arch, results[i].CompiledBinary, target.want)
+		}
+		if results[i].Platform != target.platform || results[i].Arch != target.arch {
+			t.Errorf("Build(%s/%s) changed the target to %s/%s", target.platform, target.arch, results[i].Platform, results[i].Arch)
+		}
+		if results[i].ProjectData != projectData {
+			t.Errorf("Build(%s/%s) should give back the caller's project data", target.platform, target.arch)
+		}
+	}
+	if projectData.OutputType != "desktop" || projectData.OutputFilename != "myapp" {
+		t.Errorf("the shared project data was changed by the builds: %+v", projectData)
+	}
+}
+
+func Test_writeBundleZip(t *testing.T) {
+	binDirectory := t.TempDir()
+	bundle := filepath.Join(binDirectory, "My App.app")
+	frameworkDir := filepath.Join(bundle, "Contents", "Frameworks", "Helper.framework", "Versions", "A")
+	if err := os.MkdirAll(frameworkDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(bundle, "Contents", "MacOS"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, "Contents", "MacOS", "myapp"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, "Contents", "Info.plist"), []byte("<plist/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(frameworkDir, "Helper"), []byte("library"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("A", filepath.Join(bundle, "Contents", "Frameworks", "Helper.framework", "Versions", "Current")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	zipFile := bundle + ".zip"
+	if err := writeBundleZip(&Options{}, bundle, zipFile); err != nil {
+		t.Fatalf("writeBundleZip() error = %v", err)
+	}
+	reader, err := zip.OpenReader(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	got := map[string]os.FileMode{}
+	for _, file := range reader.File {
+		got[file.Name] = file.Mode()
+		if file.Mode()&os.ModeSymlink != 0 {
+			content, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			target, _ := io.ReadAll(content)
+			content.Close()
+			if string(target) != "A" {
+				t.Errorf("symlink %s points to %q, want A", file.Name, target)
+			}
+		}
+	}
+	for name, want := range map[string]os.FileMode{
+		"My App.app/Contents/MacOS/myapp":                                   0755,
+		"My App.app/Contents/Info.plist":                                    0644,
+		"My App.app/Contents/Frameworks/Helper.framework/Versions/A/Helper": 0755,
+		"My App.app/Contents/Frameworks/Helper.framework/Versions/Current":  os.ModeSymlink,
+	} {
+		mode, found := got[name]
+		if !found {
+			t.Errorf("zip has no %s: %v", name, got)
+			continue
+		}
+		if want == os.ModeSymlink {
+			if mode&os.ModeSymlink == 0 {
+				t.Errorf("%s mode = %v, want a symlink", name, mode)
+			}
+		} else if mode.Perm() != want {
+			t.Errorf("%s mode = %v, want %v", name, mode.Perm(), want)
+		}
+	}
+	if _, found := got["My App.app/"]; !found {
+		t.Errorf("zip should have the bundle directory at its root: %v", got)
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, "internal", "node_modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	options := &Options{
+		Logger: clilogger.New(io.Discard),
+		ProjectData: &project.Project{
+			Name:       "myapp",
+			Path:       projectDir,
+			BuildDir:   filepath.Join(projectDir, "build"),
+			DebounceMS: 20,
+		},
+		// The build fails validation, which is enough to see the rebuilds
+		Platform:       "plan9",
+		IgnoreFrontend: true,
+	}
+	results := make(chan error, 10)
+	stop, err := Watch(options, nil, func(binary string, err error) {
+		results <- err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// Ignored changes don't rebuild
+	for _, file := range []string{"README.md", filepath.Join("internal", "node_modules", "dep.go")} {
+		if err := os.WriteFile(filepath.Join(projectDir, file), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case err := <-results:
+		t.Fatalf("unexpected rebuild for an ignored change: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Several quick changes rebuild once
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(projectDir, "internal", "app.go"), []byte("package internal\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case err := <-results:
+		if err == nil || !strings.Contains(err.Error(), "plan9") {
+			t.Errorf("rebuild error = %v, want the build's validation error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no rebuild after a Go source changed")
+	}
+	select {
+	case err := <-results:
+		t.Errorf("changes within the debounce time should rebuild once, got another rebuild: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	stop()
+	if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-results:
+		t.Errorf("unexpected rebuild after stop: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func Test_watchOutputDirs(t *testing.T) {
+	projectDir := t.TempDir()
+	options := &Options{
+		IgnoreFrontend:    true,
+		BindingsOutputDir: "ui/src",
+		ProjectData:       &project.Project{Path: projectDir, BuildDir: "build", WailsJSDir: "ui"},
+	}
+	want := []string{
+		filepath.Join(projectDir, "build"),
+		filepath.Join(projectDir, "ui", "wailsjs"),
+		filepath.Join(projectDir, "ui", "src", "wailsjs"),
+	}
+	if got := watchOutputDirs(options); !reflect.DeepEqual(got, want) {
+		t.Errorf("watchOutputDirs() = %v, want %v", got, want)
+	}
+	if options.WailsJSDir != "" {
+		t.Errorf("watchOutputDirs() set WailsJSDir to %q", options.WailsJSDir)
+	}
+}
+
+func Test_watchFilterTriggers(t *testing.T) {
+	projectDir := t.TempDir()
+	frontendDir := filepath.Join(projectDir, "frontend")
+	filter := &watchFilter{
+		frontendDir: frontendDir,
+		outputDirs: []string{
+			filepath.Join(projectDir, "build"),
+			filepath.Join(frontendDir, "wailsjs"),
+			filepath.Join(frontendDir, "public", "app"),
+		},
+	}
+	tests := []struct {
+		name           string
+		filename       string
+		ignoreFrontend bool
+		want           bool
+	}{
+		{name: "go source", filename: filepath.Join(projectDir, "app.go"), want: true},
+		{name: "go.mod", filename: filepath.Join(projectDir, "go.mod"), want: true},
+		{name: "readme", filename: filepath.Join(projectDir, "README.md")},
+		{name: "frontend source", filename: filepath.Join(frontendDir, "src", "main.js"), want: true},
+		{name: "frontend ignored", filename: filepath.Join(frontendDir, "src", "main.js"), ignoreFrontend: true},
+		{name: "package.json", filename: filepath.Join(frontendDir, "package.json"), want: true},
+		{name: "package-lock.json", filename: filepath.Join(frontendDir, "package-lock.json")},
+		{name: "pnpm lock", filename: filepath.Join(frontendDir, "pnpm-lock.yaml")},
+		{name: "embedded frontend", filename: filepath.Join(frontendDir, "public", "app", "index.html")},
+		{name: "frontend named dist", filename: filepath.Join(frontendDir, "src", "dist", "main.js"), want: true},
+		{name: "bindings", filename: filepath.Join(frontendDir, "wailsjs", "go", "main", "App.js")},
+		{name: "build directory", filename: filepath.Join(projectDir, "build", "bin", "app.go")},
+		{name: "node_modules created", filename: filepath.Join(frontendDir, "node_modules")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter.ignoreFrontend = tt.ignoreFrontend
+			if got := filter.triggers(tt.filename); got != tt.want {
+				t.Errorf("triggers(%s) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_selectFrontendEmbedDir(t *testing.T) {
+	embedDir := func(baseDir string, embedPath string) *staticanalysis.EmbedDetails {
+		return &staticanalysis.EmbedDetails{BaseDir: baseDir, EmbedPath: embedPath}
+	}
+	tests := []struct {
+		name    string
+		embeds  []*staticanalysis.EmbedDetails
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "the frontend's embedded directory",
+			embeds: []*staticanalysis.EmbedDetails{embedDir("/app", "frontend/dist"), embedDir("/app", "migrations"), {BaseDir: "/app", EmbedPath: "build/appicon.png", IsFile: true}},
+			want:   "/app/frontend/dist",
+		},
+		{
+			name:   "the only embedded directory",
+			embeds: []*staticanalysis.EmbedDetails{embedDir("/app", "web/build")},
+			want:   "/app/web/build",
+		},
+		{
+			name:    "several embedded directories",
+			embeds:  []*staticanalysis.EmbedDetails{embedDir("/app", "web/build"), embedDir("/app", "migrations")},
+			wantErr: true,
+		},
+		{
+			name:    "no embedded directory",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectFrontendEmbedDir(tt.embeds, "/app/frontend")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectFrontendEmbedDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != filepath.FromSlash(tt.want) {
+				t.Errorf("selectFrontendEmbedDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_replaceDirectory(t *testing.T) {
+	projectDir := t.TempDir()
+	files := map[string]string{
+		"frontend/dist/old.js":           "stale",
+		"ci/dist/index.html":             "<html></html>",
+		"ci/dist/assets/index-1a2b3c.js": "fresh",
+	}
+	for name, content := range files {
+		filename := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	distDir := filepath.Join(projectDir, "frontend", "dist")
+	if err := replaceDirectory(distDir, filepath.Join(projectDir, "ci", "dist")); err != nil {
+		t.Fatalf("replaceDirectory() error = %v", err)
+	}
+	for _, name := range []string{"index.html", filepath.Join("assets", "index-1a2b3c.js")} {
+		if !fs.FileExists(filepath.Join(distDir, name)) {
+			t.Errorf("the prebuilt %s was not copied to frontend/dist", name)
+		}
+	}
+	if fs.FileExists(filepath.Join(distDir, "old.js")) {
+		t.Errorf("the previous contents of frontend/dist should be replaced")
+	}
+
+	if err := replaceDirectory(distDir, filepath.Join(projectDir, "frontend")); err == nil {
+		t.Errorf("expected an error for a source containing the target")
+	}
+}
+
+func Test_checkFrontendEmbedDir(t *testing.T) {
+	projectDir := t.TempDir()
+	for _, name := range []string{"built/dist/index.html", "placeholder/dist/.gitkeep"} {
+		filename := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name      string
+		frontend  string
+		embedPath string
+		wantErr   bool
+	}{
+		{name: "built frontend", frontend: "built", embedPath: "built/dist"},
+		{name: "placeholder only", frontend: "placeholder", embedPath: "placeholder/dist", wantErr: true},
+		{name: "missing directory", frontend: "missing", embedPath: "missing/dist", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embeds := []*staticanalysis.EmbedDetails{{BaseDir: projectDir, EmbedPath: tt.embedPath}}
+			err := checkFrontendEmbedDir(embeds, filepath.Join(projectDir, tt.frontend))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkFrontendEmbedDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := checkFrontendEmbedDir(nil, filepath.Join(projectDir, "built")); err != nil {
+		t.Errorf("checkFrontendEmbedDir() error = %v without embeds", err)
+	}
+}
+
+func Test_writeFatBinary(t *testing.T) {
+	dir := t.TempDir()
+	// A Mach-O header without load commands, followed by a body
+	writeMachO := func(name string, cpu macho.Cpu, body string) string {
+		var buffer bytes.Buffer
+		header := []uint32{macho.Magic64, uint32(cpu), 3, uint32(macho.TypeExec), 0, 0, 0, 0}
+		if err := binary.Write(&buffer, binary.LittleEndian, header); err != nil {
+			t.Fatal(err)
+		}
+		buffer.WriteString(body)
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, buffer.Bytes(), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	amd64Binary := writeMachO("app-amd64", macho.CpuAmd64, "amd64 code")
+	arm64Binary := writeMachO("app-arm64", macho.CpuArm64, "arm64 code")
+
+	universalBinary := filepath.Join(dir, "app")
+	if err := writeFatBinary(universalBinary, amd64Binary, arm64Binary); err != nil {
+		t.Fatalf("writeFatBinary() error = %v", err)
+	}
+	fatFile, err := macho.OpenFat(universalBinary)
+	if err != nil {
+		t.Fatalf("the universal binary can't be read: %v", err)
+	}
+	defer fatFile.Close()
+	if len(fatFile.Arches) != 2 {
+		t.Fatalf("got %d architectures, want 2", len(fatFile.Arches))
+	}
+	universalData, err := os.ReadFile(universalBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, want := range []struct {
+		cpu      macho.Cpu
+		filename string
+	}{{macho.CpuAmd64, amd64Binary}, {macho.CpuArm64, arm64Binary}} {
+		arch := fatFile.Arches[index]
+		if arch.Cpu != want.cpu || arch.Offset%(1<<fatArchAlign) != 0 {
+			t.Errorf("architecture %d is %s at offset %d, want an aligned %s", index, arch.Cpu, arch.Offset, want.cpu)
+		}
+		wantData, err := os.ReadFile(want.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotData := universalData[arch.Offset : arch.Offset+arch.Size]; !bytes.Equal(gotData, wantData) {
+			t.Errorf("architecture %d holds %q, want the contents of %s", index, gotData, filepath.Base(want.filename))
+		}
+	}
+
+	if err := writeFatBinary(filepath.Join(dir, "twice"), amd64Binary, amd64Binary); err == nil {
+		t.Errorf("expected an error merging two amd64 binaries")
+	}
+	if err := writeFatBinary(filepath.Join(dir, "invalid"), amd64Binary, filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected an error for a missing binary")
+	}
+}
+
+func Test_verbosity(t *testing.T) {
+	options := &Options{
+		Verbosity:      1,
+		PhaseVerbosity: map[string]int{PhaseCompile: VERBOSE, PhaseFrontend: 0},
+	}
+	for phase, want := range map[string]int{PhaseCompile: VERBOSE, PhaseFrontend: 0, PhaseBindings: 1, "": 1} {
+		options.phase = phase
+		if got := options.verbosity(); got != want {
+			t.Errorf("verbosity() in phase %q = %d, want %d", phase, got, want)
+		}
+	}
+}
+
+func Test_BuildSilent(t *testing.T) {
+	projectDir := t.TempDir()
+	for _, verbosity := range []int{0, 1} {
+		var output bytes.Buffer
+		logger := clilogger.New(&output)
+		options := &Options{
+			Logger: logger,
+			ProjectData: &project.Project{
+				Name:           "myapp",
+				Path:           projectDir,
+				BuildDir:       filepath.Join(projectDir, "build"),
+				OutputFilename: "myapp",
+			},
+			Platform:   "linux",
+			Arch:       "amd64",
+			OutputType: "desktop",
+			Mode:       Production,
+			DryRun:     true,
+			Verbosity:  verbosity,
+		}
+		if _, err := Build(options); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if silent := output.Len() == 0; silent != (verbosity == 0) {
+			t.Errorf("Build() with verbosity %d printed %q", verbosity, output.String())
+		}
+		if options.Logger != logger {
+			t.Errorf("Build() with verbosity %d replaced the logger", verbosity)
+		}
+	}
+}
+
+func Test_selectFrontendArchive(t *testing.T) {
+	archive := func(embedPath string) *staticanalysis.EmbedDetails {
+		return &staticanalysis.EmbedDetails{BaseDir: "/app", EmbedPath: embedPath, IsFile: true}
+	}
+	tests := []struct {
+		name        string
+		embeds      []*staticanalysis.EmbedDetails
+		wantArchive string
+		wantSource  string
+		wantErr     bool
+	}{
+		{
+			name:        "gzipped tarball",
+			embeds:      []*staticanalysis.EmbedDetails{archive("frontend/dist.tar.gz"), archive("build/appicon.png"), {BaseDir: "/app", EmbedPath: "migrations"}},
+			wantArchive: "/app/frontend/dist.tar.gz",
+			wantSource:  "/app/frontend/dist",
+		},
+		{
+			name:        "tarball",
+			embeds:      []*staticanalysis.EmbedDetails{archive("web/build.tar")},
+			wantArchive: "/app/web/build.tar",
+			wantSource:  "/app/web/build",
+		},
+		{
+			name:    "several archives",
+			embeds:  []*staticanalysis.EmbedDetails{archive("frontend/dist.tgz"), archive("docs.tar")},
+			wantErr: true,
+		},
+		{
+			name:    "no archive",
+			embeds:  []*staticanalysis.EmbedDetails{{BaseDir: "/app", EmbedPath: "frontend/dist"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArchive, gotSource, err := selectFrontendArchive(tt.embeds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectFrontendArchive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotArchive != filepath.FromSlash(tt.wantArchive) || gotSource != filepath.FromSlash(tt.wantSource) {
+				t.Errorf("selectFrontendArchive() = %v, %v, want %v, %v", gotArchive, gotSource, tt.wantArchive, tt.wantSource)
+			}
+		})
+	}
+}
+
+func Test_writeFrontendArchive(t *testing.T) {
+	distDir := filepath.Join(t.TempDir(), "dist")
+	files := map[string]string{
+		"index.html":          "<html></html>",
+		"assets/index-1a2.js": "console.log('hi')",
+	}
+	for name, content := range files {
+		filename := filepath.Join(distDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readArchive := func(archive string) map[string]string {
+		file, err := os.Open(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := map[string]string{}
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return result
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.Typeflag == tar.TypeDir {
+				continue
+			}
+			content, err := io.ReadAll(tarReader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result[header.Name] = string(content)
+		}
+	}
+
+	options := &Options{}
+	archive := distDir + ".tar.gz"
+	if err := writeFrontendArchive(options, archive, distDir); err != nil {
+		t.Fatalf("writeFrontendArchive() error = %v", err)
+	}
+	if got := readArchive(archive); !reflect.DeepEqual(got, files) {
+		t.Errorf("writeFrontendArchive() archived %v, want %v", got, files)
+	}
+
+	if err := writeFrontendArchive(options, archive, filepath.Join(distDir, "missing")); err != nil {
+		t.Fatalf("writeFrontendArchive() error = %v for a missing directory", err)
+	}
+	if got := readArchive(archive); len(got) != 0 {
+		t.Errorf("writeFrontendArchive() archived %v for a missing directory, want nothing", got)
+	}
+}
+
+func Test_createEmbedDirectoriesVerbose(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, "frontend", "dist"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sourceFile := filepath.Join(projectDir, "main.go")
+	embeds := []*staticanalysis.EmbedDetails{
+		{BaseDir: projectDir, EmbedPath: "frontend/dist", All: true, SourceFile: sourceFile},
+		{BaseDir: projectDir, EmbedPath: "migrations", SourceFile: sourceFile},
+		{BaseDir: projectDir, EmbedPath: "build/appicon.png", IsFile: true, SourceFile: sourceFile},
+	}
+	for _, verbosity := range []int{VERBOSE, 1} {
+		var output bytes.Buffer
+		options := &Options{Logger: clilogger.New(&output), Verbosity: verbosity}
+		if err := createEmbedDirectories(options, embeds); err != nil {
+			t.Fatalf("createEmbedDirectories() error = %v", err)
+		}
+		if !fs.DirExists(filepath.Join(projectDir, "migrations")) {
+			t.Errorf("createEmbedDirectories() did not create the migrations directory")
+		}
+		if verbosity != VERBOSE {
+			if output.Len() > 0 {
+				t.Errorf("createEmbedDirectories() logged %q when not verbose", output.String())
+			}
+			continue
+		}
+		for _, want := range []string{
+			sourceFile + ": all:frontend/dist (" + filepath.Join(projectDir, "frontend", "dist") + ", exists)",
+			sourceFile + ": build/appicon.png (" + filepath.Join(projectDir, "build", "appicon.png") + ", file)",
+			": migrations (" + filepath.Join(projectDir, "migrations") + ", created)",
+		} {
+			if !strings.Contains(output.String(), want) {
+				t.Errorf("createEmbedDirectories() logged %q, want it to contain %q", output.String(), want)
+			}
+		}
+	}
+}
+
+func Test_hookArguments(t *testing.T) {
+	options := &Options{
+		Platform:    "darwin",
+		Arch:        "arm64",
+		Mode:        Production,
+		OutputType:  "desktop",
+		ProjectData: &project.Project{Name: "myapp"},
+	}
+	want := map[string]string{
+		"${platform}":   "darwin/arm64",
+		"${arch}":       "arm64",
+		"${mode}":       "production",
+		"${outputType}": "desktop",
+		"${name}":       "myapp",
+	}
+	if got := hookArguments(options); !reflect.DeepEqual(got, want) {
+		t.Errorf("hookArguments() = %v, want %v", got, want)
+	}
+}
+
+func Test_precompressAssets(t *testing.T) {
+	distDir := t.TempDir()
+	large := strings.Repeat("<p>Hello Wails</p>\n", 100)
+	files := map[string]string{
+		"index.html":           large,
+		"assets/index.js":      large,
+		"assets/tiny.css":      "body{}",
+		"assets/logo.png":      large,
+		"assets/font.woff2":    large,
+		"assets/data.JSON":     large,
+		"assets/nested/app.js": large,
+	}
+	for name, content := range files {
+		filename := filepath.Join(distDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := precompressAssets(&Options{PrecompressAssets: PrecompressGzip}, distDir); err != nil {
+		t.Fatalf("precompressAssets() error = %v", err)
+	}
+	for name := range files {
+		compressed := filepath.Join(distDir, filepath.FromSlash(name)+".gz")
+		want := !strings.HasSuffix(name, ".png") && !strings.HasSuffix(name, ".woff2") && name != "assets/tiny.css"
+		if got := fs.FileExists(compressed); got != want {
+			t.Errorf("%s.gz exists = %v, want %v", name, got, want)
+			continue
+		}
+		if !want {
+			continue
+		}
+		file, err := os.Open(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(gzipReader)
+		_ = file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != files[name] {
+			t.Errorf("%s.gz does not decompress to %s", name, name)
+		}
+	}
+}
+
+func Test_checkGoVersion(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	tests := []struct {
+		name       string
+		minVersion string
+		wantErr    bool
+	}{
+		{name: "wails minimum", minVersion: ""},
+		{name: "older minimum", minVersion: "1.18.1"},
+		{name: "newer minimum", minVersion: "99.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{
+				Logger:       clilogger.New(io.Discard),
+				Compiler:     "go",
+				MinGoVersion: tt.minVersion,
+			}
+			err := checkGoVersion(options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkGoVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_bindingsTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		userTags     []string
+		bindingsTags []string
+		obfuscated   bool
+		want         []string
+	}{
+		{name: "user tags", userTags: []string{"sqlite"}, want: []string{"sqlite"}},
+		{name: "bindings tags", userTags: []string{"sqlite"}, bindingsTags: []string{"sqlite", "codegen"}, want: []string{"sqlite", "codegen"}},
+		{name: "no bindings tags", userTags: []string{"sqlite"}, bindingsTags: []string{}, want: []string{}},
+		{name: "obfuscated", userTags: []string{"sqlite"}, obfuscated: true, want: []string{"sqlite", "obfuscated"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userTags := append(make([]string, 0, 4), tt.userTags...)
+			options := &Options{UserTags: userTags, BindingsTags: tt.bindingsTags, Obfuscated: tt.obfuscated}
+			for i := 0; i < 2; i++ {
+				if got := bindingsTags(options); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("bindingsTags() = %v, want %v", got, tt.want)
+				}
+			}
+			// The spare capacity of the user tags must not be written to either
+			if spare := userTags[:len(userTags)+1]; !reflect.DeepEqual(options.UserTags, tt.userTags) || spare[len(userTags)] != "" {
+				t.Errorf("bindingsTags() changed the user tags to %v", spare)
+			}
+		})
+	}
+}
+
+func Test_packageApplicationForLinuxWithoutFormat(t *testing.T) {
+	options := &Options{OutputType: "desktop", ProjectData: &project.Project{Name: "myapp"}}
+	if err := packageApplicationForLinux(options); err != nil {
+		t.Errorf("packageApplicationForLinux() error = %v, want no package without a format", err)
+	}
+	if options.CompiledBundle != "" {
+		t.Errorf("packageApplicationForLinux() created %s without a format", options.CompiledBundle)
+	}
+}
+
+func Test_buildCacheKey(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	newOptions := func(projectDir string) *Options {
+		return &Options{
+			Logger:       clilogger.New(io.Discard),
+			Compiler:     "go",
+			Platform:     "linux",
+			Arch:         "amd64",
+			BinDirectory: filepath.Join(projectDir, "build", "bin"),
+			ProjectData:  &project.Project{Path: projectDir, BuildDir: "build"},
+		}
+	}
+	writeFile := func(t *testing.T, path string, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		change      func(t *testing.T, options *Options)
+		wantChanged bool
+	}{
+		{name: "no change", change: func(t *testing.T, options *Options) {}},
+		{name: "GOFLAGS", change: func(t *testing.T, options *Options) { t.Setenv("GOFLAGS", "-mod=mod") }, wantChanged: true},
+		{name: "CGO_CFLAGS", change: func(t *testing.T, options *Options) { t.Setenv("CGO_CFLAGS", "-O3") }, wantChanged: true},
+		{name: "CGO_LDFLAGS", change: func(t *testing.T, options *Options) { t.Setenv("CGO_LDFLAGS", "-lm") }, wantChanged: true},
+		{name: "mac min version", change: func(t *testing.T, options *Options) { options.MacMinVersion = "11.0" }, wantChanged: true},
+		{name: "offline", change: func(t *testing.T, options *Options) { options.Offline = true }, wantChanged: true},
+		{name: "use vendor", change: func(t *testing.T, options *Options) { options.UseVendor = true }, wantChanged: true},
+		{name: "user tags", change: func(t *testing.T, options *Options) { options.UserTags = []string{"sqlite"} }, wantChanged: true},
+		{
+			name: "source file",
+			change: func(t *testing.T, options *Options) {
+				writeFile(t, filepath.Join(options.ProjectData.Path, "main.go"), "package main\n\nfunc main() { println() }\n")
+			},
+			wantChanged: true,
+		},
+		{
+			name: "binary sizes record",
+			change: func(t *testing.T, options *Options) {
+				writeFile(t, filepath.Join(options.ProjectData.GetBuildDir(), binarySizesFile), `{"linux/amd64":1}`)
+			},
+		},
+		{
+			name: "frontend hash record",
+			change: func(t *testing.T, options *Options) {
+				writeFile(t, filepath.Join(options.ProjectData.GetBuildDir(), frontendHashFile), "abc")
+			},
+		},
+		{
+			name: "bin directory",
+			change: func(t *testing.T, options *Options) {
+				writeFile(t, filepath.Join(options.BinDirectory, "myapp"), "binary")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range cacheKeyEnvironment {
+				t.Setenv(name, "")
+			}
+			projectDir := t.TempDir()
+			writeFile(t, filepath.Join(projectDir, "main.go"), "package main\n\nfunc main() {}\n")
+
+			base, err := buildCacheKey(newOptions(projectDir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			options := newOptions(projectDir)
+			tt.change(t, options)
+			got, err := buildCacheKey(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := got != base; changed != tt.wantChanged {
+				t.Errorf("buildCacheKey() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+type countingCompileBuilder struct {
+	*BaseBuilder
+	compiles int
+}
+
+func (c *countingCompileBuilder) CompileProject(options *Options) error {
+	c.compiles++
+	options.CompiledBinary = filepath.Join(options.BinDirectory, c.OutputFilename(options))
+	if err := os.MkdirAll(options.BinDirectory, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(options.CompiledBinary, []byte("binary"), 0755)
+}
+
+func Test_compileProjectWithCache(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	tests := []struct {
+		name         string
+		forceBuild   bool
+		populate     bool
+		wantCompiles int
+	}{
+		{name: "miss", wantCompiles: 1},
+		{name: "hit", populate: true, wantCompiles: 0},
+		{name: "force build", populate: true, forceBuild: true, wantCompiles: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			t.Setenv("HOME", t.TempDir())
+			projectDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			newOptions := func() *Options {
+				return &Options{
+					Logger:           clilogger.New(io.Discard),
+					Compiler:         "go",
+					Platform:         "linux",
+					Arch:             "amd64",
+					OutputFile:       "myapp",
+					EnableBuildCache: true,
+					BinDirectory:     filepath.Join(projectDir, "build", "bin"),
+					ProjectData:      &project.Project{Path: projectDir, BuildDir: "build"},
+				}
+			}
+			if tt.populate {
+				options := newOptions()
+				if err := compileProjectWithCache(&countingCompileBuilder{BaseBuilder: NewBaseBuilder(options)}, options); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.RemoveAll(options.BinDirectory); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			options := newOptions()
+			options.ForceBuild = tt.forceBuild
+			builder := &countingCompileBuilder{BaseBuilder: NewBaseBuilder(options)}
+			if err := compileProjectWithCache(builder, options); err != nil {
+				t.Fatalf("compileProjectWithCache() error = %v", err)
+			}
+			if builder.compiles != tt.wantCompiles {
+				t.Errorf("compileProjectWithCache() compiled %d times, want %d", builder.compiles, tt.wantCompiles)
+			}
+			want := filepath.Join(options.BinDirectory, "myapp")
+			if options.CompiledBinary != want || !fs.FileExists(want) {
+				t.Errorf("compileProjectWithCache() CompiledBinary = %q, want %q to exist", options.CompiledBinary, want)
+			}
+		})
+	}
+}
+
+func Test_CleanTwice(t *testing.T) {
+	projectDir := t.TempDir()
+	options := &Options{ProjectData: &project.Project{Name: "myapp", Path: projectDir, BuildDir: "build"}}
+	for i := 0; i < 2; i++ {
+		if err := Clean(options); err != nil {
+			t.Fatalf("Clean() call %d error = %v", i+1, err)
+		}
+	}
+	if fs.DirExists(filepath.Join(projectDir, "build", "bin")) {
+		t.Error("Clean() left the bin directory")
+	}
+}
+
+func Test_checkBinDirectoryRemovable(t *testing.T) {
+	projectDir := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		binDirectory string
+		wantErr      bool
+	}{
+		{name: "bin directory", binDirectory: filepath.Join(projectDir, "build", "bin")},
+		{name: "empty", binDirectory: "", wantErr: true},
+		{name: "project directory", binDirectory: projectDir, wantErr: true},
+		{name: "parent of project", binDirectory: filepath.Dir(projectDir), wantErr: true},
+		{name: "working directory", binDirectory: cwd, wantErr: true},
+		{name: "relative working directory", binDirectory: ".", wantErr: true},
+		{name: "filesystem root", binDirectory: string(filepath.Separator), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{BinDirectory: tt.binDirectory, ProjectData: &project.Project{Path: projectDir}}
+			err := checkBinDirectoryRemovable(options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkBinDirectoryRemovable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && removeBinDirectory(options) == nil {
+				t.Error("removeBinDirectory() removed a protected directory")
+			}
+		})
+	}
+}
+
+func Test_validateOptions(t *testing.T) {
+	// The archs are checked against the ones the compiler lists
+	compiler, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not found on PATH")
+	}
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, "frontend"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cgoDisabled := false
+	newOptions := func() *Options {
+		return &Options{
+			Compiler:    compiler,
+			Platform:    "linux",
+			Arch:        "amd64",
+			OutputType:  "desktop",
+			ProjectData: &project.Project{Name: "myapp", Path: projectDir},
+		}
+	}
+	tests := []struct {
+		name    string
+		change  func(options *Options)
+		wantErr string
+	}{
+		{name: "valid", change: func(options *Options) {}},
+		{name: "no project data", change: func(options *Options) { options.ProjectData = nil }, wantErr: "no project data given"},
+		{name: "working directory", change: func(options *Options) { options.WorkingDir = filepath.Join(projectDir, "missing") }, wantErr: "working directory"},
+		{name: "project name", change: func(options *Options) { options.ProjectData.Name = "" }, wantErr: "the project has no name"},
+		{name: "project directory", change: func(options *Options) { options.ProjectData.Path = filepath.Join(projectDir, "missing") }, wantErr: "project directory"},
+		{name: "frontend directory", change: func(options *Options) { options.ProjectData.FrontendDir = "ui" }, wantErr: "frontend directory"},
+		{name: "frontend ignored", change: func(options *Options) { options.ProjectData.FrontendDir = "ui"; options.IgnoreFrontend = true }},
+		{name: "output type", change: func(options *Options) { options.OutputType = "mobile" }, wantErr: "output type 'mobile' is not supported"},
+		{name: "optimization profile", change: func(options *Options) { options.OptimizeFor = "fun" }, wantErr: "optimization profile 'fun'"},
+		{name: "build mode", change: func(options *Options) { options.BuildMode = "plugin" }, wantErr: "build mode 'plugin'"},
+		{name: "universal shared library", change: func(options *Options) {
+			options.Platform, options.Arch, options.BuildMode = "darwin", "universal", BuildModeCShared
+		}, wantErr: "shared libraries cannot be built as universal binaries"},
+		{name: "linux package format", change: func(options *Options) { options.LinuxPackageFormat = "rpm" }, wantErr: "linux package format 'rpm'"},
+		{name: "package manager", change: func(options *Options) { options.FrontendPackageManager = "deno" }, wantErr: "frontend package manager 'deno'"},
+		{name: "minimum go version", change: func(options *Options) { options.MinGoVersion = "latest" }, wantErr: "invalid minimum Go version 'latest'"},
+		{name: "precompression", change: func(options *Options) { options.PrecompressAssets = "zstd" }, wantErr: "asset precompression 'zstd'"},
+		{name: "mod tidy mode", change: func(options *Options) { options.ModTidyMode = "always" }, wantErr: "mod tidy mode 'always'"},
+		{name: "platform", change: func(options *Options) { options.Platform = "plan9" }, wantErr: "platform 'plan9' is not supported. Supported platforms: darwin, freebsd, linux, windows"},
+		{name: "arch", change: func(options *Options) { options.Platform, options.Arch = "windows", "mips" }, wantErr: "arch 'mips' is not supported for platform 'windows'"},
+		{name: "linux 386", change: func(options *Options) { options.Arch = "386" }},
+		{name: "linux archs", change: func(options *Options) { options.Arch = "riscv64,ppc64le,mips" }},
+		{name: "one of several archs", change: func(options *Options) { options.Arch = "amd64, sparc" }, wantErr: "arch ' sparc' is not supported"},
+		{name: "universal on linux", change: func(options *Options) { options.Arch = "universal" }, wantErr: "arch 'universal' is not supported for platform 'linux'"},
+		{name: "compiler", change: func(options *Options) { options.Compiler = filepath.Join(projectDir, "go") }, wantErr: "compiler '"},
+		{name: "C compiler", change: func(options *Options) { options.CC = "missing-${arch}-gcc" }, wantErr: "C compiler 'missing-amd64-gcc' not found"},
+		{name: "C++ compiler", change: func(options *Options) { options.CXX = "missing-g++" }, wantErr: "C++ compiler 'missing-g++' not found"},
+		{name: "entry point", change: func(options *Options) { options.EntryPoint = "cmd/missing" }, wantErr: "entry point 'cmd/missing' does not exist"},
+		{name: "PGO profile", change: func(options *Options) { options.PGOProfile = "missing.pgo" }, wantErr: "PGO profile 'missing.pgo' does not exist"},
+		{name: "PGO auto", change: func(options *Options) { options.PGOProfile = PGOAuto }},
+		{name: "linux file mode", change: func(options *Options) { options.LinuxFileModes = map[string]string{"usr/*": "rwx"} }, wantErr: "invalid Linux file mode for 'usr/*'"},
+		{name: "output name template", change: func(options *Options) { options.OutputNameTemplate = "{name}-{unknown}" }, wantErr: "{unknown}"},
+		{name: "link variable", change: func(options *Options) { options.LinkVars = map[string]string{"version": "1.0"} }, wantErr: "invalid link variable 'version'"},
+		{name: "ldflags target", change: func(options *Options) { options.PlatformLDFlags = map[string]string{"linux/sparc": "-s"} }, wantErr: "invalid ldflags target 'linux/sparc'"},
+		{name: "ldflags platform", change: func(options *Options) { options.PlatformLDFlags = map[string]string{"plan9": "-s"} }, wantErr: "invalid ldflags target 'plan9'"},
+		{name: "ldflags linux 386", change: func(options *Options) { options.PlatformLDFlags = map[string]string{"linux/386": "-s"} }},
+		{name: "ldflags all archs", change: func(options *Options) { options.PlatformLDFlags = map[string]string{"linux/*": "-s"} }},
+		{name: "verbosity phase", change: func(options *Options) { options.PhaseVerbosity = map[string]int{"linking": 2} }, wantErr: "unknown build phase 'linking'"},
+		{name: "verbosity level", change: func(options *Options) { options.PhaseVerbosity = map[string]int{PhaseCompile: 3} }, wantErr: "invalid verbosity 3 for the compile phase"},
+		{name: "negative backups", change: func(options *Options) { options.CleanBackupsToKeep = -1 }, wantErr: "backups to keep must not be negative"},
+		{name: "backups without backing up", change: func(options *Options) { options.CleanBackupsToKeep = 2 }, wantErr: "only be given when backing up"},
+		{name: "parallelism", change: func(options *Options) { options.CompileParallelism = -1 }, wantErr: "parallel compile jobs must not be negative"},
+		{name: "build info prefix", change: func(options *Options) { options.BuildInfoVarPrefix = "main" }, wantErr: "only be used when injecting the build info"},
+		{name: "icon file", change: func(options *Options) { options.LinuxIconFile = "missing.png" }, wantErr: "missing.png"},
+		{name: "mac min version format", change: func(options *Options) { options.MacMinVersion = "ventura" }, wantErr: "invalid macOS minimum version 'ventura': must be in the form"},
+		{name: "mac min version too old", change: func(options *Options) { options.MacMinVersion = "10.9" }, wantErr: "Wails applications require macOS"},
+		{name: "plist key", change: func(options *Options) { options.MacPlistExtras = PlistExtras{"": "value"} }, wantErr: "keys must not be empty"},
+		{name: "plist value", change: func(options *Options) { options.MacPlistExtras = PlistExtras{"Key": func() {}} }, wantErr: "invalid Info.plist value for 'Key'"},
+		{name: "prebuilt frontend", change: func(options *Options) { options.PrebuiltFrontendDir = "missing" }, wantErr: "a prebuilt frontend can only be used when not building the frontend"},
+		{name: "zip bundle", change: func(options *Options) { options.ZipBundle = true }, wantErr: "zipping the .app bundle requires packaging"},
+		{name: "notarization", change: func(options *Options) { options.NotarizeProfile = "profile" }, wantErr: "notarization requires a macOS signing identity"},
+		{name: "checksum signing", change: func(options *Options) { options.GPGSigningKey = "key" }, wantErr: "signing checksums requires generating checksums"},
+		{name: "strip with race detector", change: func(options *Options) { options.StripSymbols, options.RaceDetector = true, true }, wantErr: "cannot strip symbols"},
+		{name: "race detector target", change: func(options *Options) { options.Arch, options.RaceDetector = "arm", true }, wantErr: "the race detector is not supported for linux/arm"},
+		{name: "race detector without CGO", change: func(options *Options) { options.CGOEnabled, options.RaceDetector = &cgoDisabled, true }, wantErr: "the race detector requires CGO"},
+		{name: "shared library without CGO", change: func(options *Options) { options.CGOEnabled, options.BuildMode = &cgoDisabled, BuildModeCShared }, wantErr: "building a shared library requires CGO"},
+		{name: "AMD64 level", change: func(options *Options) { options.AMD64Level = "v5" }, wantErr: "invalid AMD64 level 'v5'"},
+		{name: "GOARM", change: func(options *Options) { options.GOARM = "8" }, wantErr: "invalid GOARM version '8'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := newOptions()
+			tt.change(options)
+			err := validateOptions(options)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateOptions() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateOptions() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// All the problems are reported at once, in a fixed order
+	options := newOptions()
+	options.Platform = "plan9"
+	options.CC = "missing-gcc"
+	options.CXX = "missing-g++"
+	options.GOARM = "8"
+	want := "invalid build options:\n" +
+		"  - platform 'plan9' is not supported. Supported platforms: darwin, freebsd, linux, windows\n" +
+		"  - C compiler 'missing-gcc' not found or not executable\n" +
+		"  - C++ compiler 'missing-g++' not found or not executable\n" +
+		"  - invalid GOARM version '8': must be one of 5, 6 or 7"
+	for i := 0; i < 10; i++ {
+		if err := validateOptions(options); err == nil || err.Error() != want {
+			t.Fatalf("validateOptions() error = %v, want %q", err, want)
+		}
+	}
+}
+
+func Test_LoadOptionsFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *Options
+		wantErr string
+	}{
+		{
+			name:    "options",
+			content: `{"platform": "windows", "Arch": "arm64", "Mode": "production", "HookTimeout": "90s", "UserTags": ["sqlite"], "LinkVars": {"main.version": "1.0"}}`,
+			want: &Options{
+				Platform:    "windows",
+				Arch:        "arm64",
+				Mode:        Production,
+				HookTimeout: 90 * time.Second,
+				UserTags:    []string{"sqlite"},
+				LinkVars:    map[string]string{"main.version": "1.0"},
+			},
+		},
+		{name: "empty", content: `{}`, want: &Options{}},
+		{name: "not loaded", content: `{"CompiledBinary": "app", "Timings": {"compile": 1}}`, want: &Options{}},
+		{name: "invalid json", content: `{"Platform": `, wantErr: "unable to load build options from"},
+		{name: "invalid mode", content: `{"Mode": "release"}`, wantErr: "invalid mode 'release'"},
+		{name: "invalid hook timeout", content: `{"HookTimeout": "soon"}`, wantErr: "invalid HookTimeout"},
+		{name: "wrong type", content: `{"Platform": 1}`, wantErr: "unable to load build options from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wails.build.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadOptionsFromFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadOptionsFromFile() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadOptionsFromFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadOptionsFromFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadOptionsFromFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("LoadOptionsFromFile() error = %v, want a not exist error", err)
+	}
+}
+
+func Test_expandHookEnvironment(t *testing.T) {
+	t.Setenv("WAILS_HOOK_TARGET", "release")
+	t.Setenv("WAILS_HOOK_EMPTY", "")
+	argReplacements := map[string]string{"${platform}": "linux/amd64", "${bin}": "/bin/app"}
+	tests := []struct {
+		name    string
+		hook    string
+		want    string
+		wantErr string
+	}{
+		{name: "no tokens", hook: "make all", want: "make all"},
+		{name: "environment variable", hook: "make ${WAILS_HOOK_TARGET}", want: "make release"},
+		{name: "empty environment variable", hook: "make${WAILS_HOOK_EMPTY} all", want: "make all"},
+		{name: "hook token", hook: "sign ${bin} ${platform}", want: "sign ${bin} ${platform}"},
+		{name: "both", hook: "copy ${bin} dist/${WAILS_HOOK_TARGET}", want: "copy ${bin} dist/release"},
+		{name: "unknown token", hook: "make ${WAILS_HOOK_MISSING}", wantErr: "unknown token(s) ${WAILS_HOOK_MISSING}"},
+		{name: "unknown tokens", hook: "${WAILS_HOOK_MISSING} ${arch}", wantErr: "unknown token(s) ${WAILS_HOOK_MISSING}, ${arch}"},
+		{name: "empty token", hook: "make ${}", wantErr: "unknown token(s) ${}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandHookEnvironment(tt.hook, argReplacements)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expandHookEnvironment() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandHookEnvironment() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expandHookEnvironment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_multiArchOutputFilename(t *testing.T) {
+	tests := []struct {
+		name               string
+		platform           string
+		arch               string
+		outputFile         string
+		outputNameTemplate string
+		buildMode          string
+		want               string
+	}{
+		{name: "linux amd64", platform: "linux", arch: "amd64", want: "myapp-linux-amd64"},
+		{name: "linux arm64", platform: "linux", arch: "arm64", want: "myapp-linux-arm64"},
+		{name: "linux arm", platform: "linux", arch: "arm", want: "myapp-linux-arm"},
+		{name: "windows amd64", platform: "windows", arch: "amd64", want: "myapp-amd64.exe"},
+		{name: "windows 386", platform: "windows", arch: "386", want: "myapp-386.exe"},
+		{name: "darwin arm64", platform: "darwin", arch: "arm64", want: "myapp-darwin-arm64"},
+		{name: "output file", platform: "linux", arch: "arm64", outputFile: "app", want: "app-linux-arm64"},
+		{name: "windows output file", platform: "windows", arch: "arm64", outputFile: "app.exe", want: "app-arm64.exe"},
+		{name: "template with arch", platform: "windows", arch: "arm64", outputNameTemplate: "{name}_{arch}", want: "myapp_arm64.exe"},
+		{name: "template without arch", platform: "windows", arch: "arm64", outputNameTemplate: "{name}", want: "myapp-arm64.exe"},
+		{name: "windows shared library", platform: "windows", arch: "amd64", buildMode: BuildModeCShared, want: "myapp-amd64.dll"},
+		{name: "shared library output file", platform: "linux", arch: "arm64", outputFile: "libapp.so", buildMode: BuildModeCShared, want: "libapp-arm64.so"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{
+				Compiler:           "go",
+				Platform:           tt.platform,
+				Arch:               strings.Join([]string{"amd64", tt.arch}, ","),
+				OutputFile:         tt.outputFile,
+				OutputNameTemplate: tt.outputNameTemplate,
+				BuildMode:          tt.buildMode,
+				ProjectData:        &project.Project{Name: "myapp", OutputFilename: "myapp", OutputType: "desktop"},
+			}
+			builder := NewBaseBuilder(options)
+			builder.SetProjectData(options.ProjectData)
+			if got := multiArchOutputFilename(builder, options, tt.arch); got != tt.want {
+				t.Errorf("multiArchOutputFilename() = %v, want %v", got, tt.want)
+			}
+			if options.Arch != "amd64,"+tt.arch || options.OutputFile != tt.outputFile {
+				t.Errorf("multiArchOutputFilename() changed the options to %s, %s", options.Arch, options.OutputFile)
+			}
+		})
+	}
+}
+
+func Test_BuildDryRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		platform     string
+		arch         string
+		wantCommands []string
+		wantBinary   string
+	}{
+		{name: "linux", platform: "linux", arch: "amd64", wantCommands: []string{"myapp-linux-amd64"}, wantBinary: "myapp-linux-amd64"},
+		{name: "windows", platform: "windows", arch: "arm64", wantCommands: []string{"myapp.exe"}, wantBinary: "myapp.exe"},
+		{name: "multiple archs", platform: "linux", arch: "amd64,arm64", wantCommands: []string{"myapp-linux-amd64", "myapp-linux-arm64"}, wantBinary: "myapp-linux-amd64"},
+		{name: "universal", platform: "darwin", arch: "universal", wantCommands: []string{"myapp-darwin-universal-amd64", "myapp-darwin-universal-arm64", "myapp-darwin-universal"}, wantBinary: "myapp-darwin-universal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.platform == "darwin" && runtime.GOOS != "darwin" {
+				t.Skip("darwin builds look up the host system, which is slow elsewhere")
+			}
+			projectDir := t.TempDir()
+			var output bytes.Buffer
+			options := &Options{
+				Logger:      clilogger.New(&output),
+				Compiler:    "go",
+				Platform:    tt.platform,
+				Arch:        tt.arch,
+				OutputType:  "desktop",
+				Mode:        Production,
+				DryRun:      true,
+				Verbosity:   1,
+				ProjectData: &project.Project{Name: "myapp", Path: projectDir, BuildDir: "build", OutputFilename: "myapp"},
+			}
+			binary, err := Build(options)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if filepath.Base(binary) != tt.wantBinary {
+				t.Errorf("Build() = %s, want %s", binary, tt.wantBinary)
+			}
+			dryRuns := 0
+			for _, line := range strings.Split(output.String(), "\n") {
+				if strings.Contains(line, "Dry run: ") {
+					dryRuns++
+				}
+			}
+			if dryRuns != len(tt.wantCommands) {
+				t.Errorf("Build() reported %d commands, want %d:\n%s", dryRuns, len(tt.wantCommands), output.String())
+			}
+			for _, want := range tt.wantCommands {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("Build() output does not mention %s:\n%s", want, output.String())
+				}
+			}
+			entries, err := os.ReadDir(projectDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("Build() wrote %d entries to the project in a dry run, want none", len(entries))
+			}
+		})
+	}
+}
+
+type universalCompileBuilder struct {
+	*BaseBuilder
+	lock     sync.Mutex
+	archs    []string
+	starting sync.WaitGroup
+	wait     bool
+}
+
+func (u *universalCompileBuilder) CompileProject(options *Options) error {
+	u.lock.Lock()
+	u.archs = append(u.archs, options.Arch)
+	u.lock.Unlock()
+	if u.wait {
+		// Each target waits for the other to start, so the compiles must run at the same time
+		u.starting.Done()
+		started := make(chan struct{})
+		go func() {
+			u.starting.Wait()
+			close(started)
+		}()
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			return errors.New("the other target was not compiled concurrently")
+		}
+	}
+	options.Logger.Println("Compiled %s", options.Arch)
+	options.CompiledBinary = filepath.Join(options.BinDirectory, options.OutputFile)
+	return os.WriteFile(options.CompiledBinary, []byte(options.Arch), 0755)
+}
+
+func Test_execBuildApplicationUniversal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses the true command as lipo")
+	}
+	tests := []struct {
+		name       string
+		sequential bool
+		wantArchs  []string
+	}{
+		{name: "concurrent", wantArchs: []string{"amd64", "arm64"}},
+		{name: "sequential", sequential: true, wantArchs: []string{"amd64", "arm64"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binDirectory := t.TempDir()
+			var output bytes.Buffer
+			options := &Options{
+				Logger:                   clilogger.New(&output),
+				Compiler:                 "go",
+				Platform:                 "darwin",
+				Arch:                     "universal",
+				OutputType:               "desktop",
+				OutputFile:               "myapp",
+				BinDirectory:             binDirectory,
+				LipoPath:                 "true",
+				SkipModTidy:              true,
+				AllowEmptyEmbeds:         true,
+				SequentialUniversalBuild: tt.sequential,
+				UserTags:                 []string{"shared"}[:1:1],
+				ProjectData:              &project.Project{Name: "myapp", Path: t.TempDir(), OutputFilename: "myapp"},
+			}
+			builder := &universalCompileBuilder{BaseBuilder: NewBaseBuilder(options), wait: !tt.sequential}
+			builder.starting.Add(2)
+			builder.SetProjectData(options.ProjectData)
+			binary, err := execBuildApplication(builder, options)
+			if err != nil {
+				t.Fatalf("execBuildApplication() error = %v", err)
+			}
+			if want := filepath.Join(binDirectory, "myapp"); binary != want {
+				t.Errorf("execBuildApplication() = %s, want %s", binary, want)
+			}
+			archs := append([]string{}, builder.archs...)
+			if !tt.sequential {
+				sort.Strings(archs)
+			}
+			if !reflect.DeepEqual(archs, tt.wantArchs) {
+				t.Errorf("compiled archs = %v, want %v", builder.archs, tt.wantArchs)
+			}
+			if want := "Compiled amd64\nCompiled arm64\n"; !strings.Contains(output.String(), want) {
+				t.Errorf("execBuildApplication() logged %q, want the targets' output in order %q", output.String(), want)
+			}
+			if options.Arch != "universal" || options.OutputFile != "myapp" || !reflect.DeepEqual(options.UserTags, []string{"shared"}) {
+				t.Errorf("execBuildApplication() changed the options to %s, %s, %v", options.Arch, options.OutputFile, options.UserTags)
+			}
+			for _, slice := range []string{"myapp-amd64", "myapp-arm64"} {
+				if fs.FileExists(filepath.Join(binDirectory, slice)) {
+					t.Errorf("the %s slice was not removed", slice)
+				}
+			}
+		})
+	}
+}
+
+func Test_createEmbedDirectoriesPlaceholder(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *Options
+		want    string
+	}{
+		{name: "zero value options", options: &Options{}, want: DefaultEmbedPlaceholderName},
+		{name: "custom placeholder", options: &Options{EmbedPlaceholderName: "README"}, want: "README"},
+		{name: "no placeholder", options: &Options{NoEmbedPlaceholder: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			embeds := []*staticanalysis.EmbedDetails{{BaseDir: projectDir, EmbedPath: "frontend/dist", All: true}}
+			if err := createEmbedDirectories(tt.options, embeds); err != nil {
+				t.Fatalf("createEmbedDirectories() error = %v", err)
+			}
+			entries, err := os.ReadDir(filepath.Join(projectDir, "frontend", "dist"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			var want []string
+			if tt.want != "" {
+				want = []string{tt.want}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("createEmbedDirectories() created %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// defaultBuildInfoVarPrefix is the package whose variables receive the build info
+const defaultBuildInfoVarPrefix = "main"
+
+// unknownBuildInfo is injected for the git details when the project is not in a git repository
+const unknownBuildInfo = "unknown"
+
+// buildInfoLDFlags returns the `-X` linker flags that inject the build info into the variables
+// of the BuildInfoVarPrefix package, `main` by default:
+//   - commit: the git commit being built, EG: 8f2c1d...
+//   - dirty: "true" if the working tree has uncommitted changes, otherwise "false"
+//   - buildTime: the given time in UTC, in RFC 3339 format
+//
+// The variables must be declared as package level strings, EG: `var commit string`.
+// The commit and dirty state are "unknown" if the project is not in a git repository.
+func buildInfoLDFlags(options *Options, buildTime time.Time) string {
+	commit, dirty := unknownBuildInfo, unknownBuildInfo
+	stdout, _, err := shell.RunCommandWithContext(options.buildContext(), options.ProjectData.Path, "git", "rev-parse", "HEAD")
+	if err == nil {
+		commit = strings.TrimSpace(stdout)
+		stdout, _, err = shell.RunCommandWithContext(options.buildContext(), options.ProjectData.Path, "git", "status", "--porcelain")
+		if err == nil {
+			dirty = "false"
+			if strings.TrimSpace(stdout) != "" {
+				dirty = "true"
+			}
+		}
+	}
+
+	prefix, _ := lo.Coalesce(options.BuildInfoVarPrefix, defaultBuildInfoVarPrefix)
+	return strings.Join([]string{
+		"-X " + prefix + ".commit=" + commit,
+		"-X " + prefix + ".dirty=" + dirty,
+		"-X " + prefix + ".buildTime=" + buildTime.UTC().Format(time.RFC3339),
+	}, " ")
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// zipMacBundle creates a <name>.app.zip of the .app bundle in the bin directory, with the bundle
+// directory at its root as `ditto -c -k --keepParent` does. ditto is used when available as it also
+// keeps resource forks and extended attributes. Otherwise the zip is written in Go, which keeps
+// the file modes and symlinks, such as those of embedded frameworks.
+func zipMacBundle(options *Options) error {
+	zipFile := options.CompiledBundle + ".zip"
+	_ = os.Remove(zipFile)
+	if shell.CommandExists("ditto") {
+		_, stderr, err := shell.RunCommandWithContext(options.buildContext(), options.BinDirectory, "ditto", "-c", "-k", "--keepParent", options.CompiledBundle, zipFile)
+		if err != nil {
+			return fmt.Errorf("unable to zip application: %s\n%s", err.Error(), stderr)
+		}
+	} else if err := writeBundleZip(options, options.CompiledBundle, zipFile); err != nil {
+		return fmt.Errorf("unable to zip application: %w", err)
+	}
+	options.addArtifact(zipFile)
+	return nil
+}
+
+// writeBundleZip writes the given bundle directory, including itself, to the given zip file
+func writeBundleZip(options *Options, bundle string, zipFile string) error {
+	output, err := os.Create(zipFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	zipWriter := zip.NewWriter(output)
+	parent := filepath.Dir(bundle)
+	err = filepath.Walk(bundle, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		// FileInfoHeader records the unix mode, including the symlink and executable bits
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if options.Reproducible {
+			header.Modified = options.buildTimestamp()
+		}
+		switch {
+		case info.IsDir():
+			header.Name += "/"
+			_, err = zipWriter.CreateHeader(header)
+			return err
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			writer, err := zipWriter.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(writer, target)
+			return err
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("'%s' is not a regular file, directory or symlink", strings.TrimPrefix(path, parent))
+		}
+		header.Method = zip.Deflate
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(writer, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
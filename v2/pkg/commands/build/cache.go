@@ -0,0 +1,191 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// buildCacheDir returns the directory that holds the cached builds
+func buildCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "wails", "build"), nil
+}
+
+// ClearBuildCache removes all the cached builds
+func ClearBuildCache() error {
+	cacheDir, err := buildCacheDir()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(cacheDir)
+}
+
+// cacheKeyEnvironment lists the inherited environment variables that change the compiled binary
+var cacheKeyEnvironment = []string{
+	"GOFLAGS", "GOEXPERIMENT", "GOTOOLCHAIN", "CGO_ENABLED", "CGO_CFLAGS", "CGO_CPPFLAGS", "CGO_CXXFLAGS", "CGO_LDFLAGS", "CC", "CXX",
+}
+
+// generatedBuildRecords lists the files of the project build directory that builds rewrite, so they
+// are left out of the cache key
+var generatedBuildRecords = []string{binarySizesFile, frontendHashFile}
+
+// compileProjectWithCache compiles the project. If the build cache is enabled and a binary
+// was previously compiled from the same sources and options, it is copied from the cache instead.
+// ForceBuild always compiles, refreshing the cached binary.
+func compileProjectWithCache(builder Builder, options *Options) error {
+	if !options.EnableBuildCache || options.DryRun {
+		return builder.CompileProject(options)
+	}
+
+	cacheDir, err := buildCacheDir()
+	if err != nil {
+		return err
+	}
+	key, err := buildCacheKey(options)
+	if err != nil {
+		return err
+	}
+	cachedBinary := filepath.Join(cacheDir, key, "binary")
+
+	if fs.FileExists(cachedBinary) && !options.ForceBuild {
+		if options.CleanBinDirectory {
+			err = cleanBinDirectory(options)
+			if err != nil {
+				return err
+			}
+		}
+		err = fs.MkDirs(options.BinDirectory, 0755)
+		if err != nil {
+			return err
+		}
+		compiledBinary := filepath.Join(options.BinDirectory, builder.OutputFilename(options))
+		err = fs.CopyFile(cachedBinary, compiledBinary)
+		if err != nil {
+			return err
+		}
+		err = os.Chmod(compiledBinary, 0755)
+		if err != nil {
+			return err
+		}
+		options.CompiledBinary = compiledBinary
+		options.Logger.Print("(cached) ")
+		return nil
+	}
+
+	err = builder.CompileProject(options)
+	if err != nil {
+		return err
+	}
+
+	err = fs.MkDirs(filepath.Dir(cachedBinary), 0755)
+	if err != nil {
+		return err
+	}
+	return fs.CopyFile(options.CompiledBinary, cachedBinary)
+}
+
+// buildCacheKey returns a hash of everything that affects the compiled binary: the Go toolchain, the
+// options and environment used to compile it and all the files in the project, excluding node_modules,
+// the bin directory and the generated build records. This covers the Go sources, the generated bindings
+// and the embedded frontend.
+func buildCacheKey(options *Options) (string, error) {
+	hash := sha256.New()
+
+	toolchain, err := goVersion(options)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine Go version: %w", err)
+	}
+	environment := map[string]string{}
+	for _, name := range cacheKeyEnvironment {
+		environment[name] = os.Getenv(name)
+	}
+
+	settings, err := json.Marshal(map[string]interface{}{
+		"compiler":         options.Compiler,
+		"goVersion":        strings.TrimSpace(toolchain),
+		"environment":      environment,
+		"offline":          options.Offline,
+		"useVendor":        options.UseVendor,
+		"macMinVersion":    macMinVersion(options),
+		"platform":         options.Platform,
+		"arch":             options.Arch,
+		"mode":             options.Mode,
+		"outputType":       options.OutputType,
+		"buildMode":        options.BuildMode,
+		"entryPoint":       options.EntryPoint,
+		"ldflags":          resolveLDFlags(options),
+		"extraGoFlags":     options.ExtraGoFlags,
+		"userTags":         options.UserTags,
+		"webview2Strategy": options.WebView2Strategy,
+		"trimPath":         options.TrimPath,
+		"reproducible":     options.Reproducible,
+		"raceDetector":     options.RaceDetector,
+		"cgoEnabled":       options.CGOEnabled,
+		"cc":               options.CC,
+		"cxx":              options.CXX,
+		"obfuscated":       options.Obfuscated,
+		"garbleArgs":       options.GarbleArgs,
+		"garbleExclude":    options.ObfuscationExclude,
+		"compressMethod":   options.CompressMethod,
+		"compressFlags":    options.CompressFlags,
+		"amd64Level":       options.AMD64Level,
+		"goarm":            options.GOARM,
+		"pgoProfile":       options.PGOProfile,
+	})
+	if err != nil {
+		return "", err
+	}
+	_, _ = hash.Write(settings)
+
+	projectDir := options.ProjectData.Path
+	binDir, _ := filepath.Abs(options.BinDirectory)
+	buildRecords := map[string]bool{}
+	for _, record := range generatedBuildRecords {
+		recordPath, _ := filepath.Abs(filepath.Join(options.ProjectData.GetBuildDir(), record))
+		buildRecords[recordPath] = true
+	}
+	err = filepath.WalkDir(projectDir, func(path string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			absPath, _ := filepath.Abs(path)
+			if entry.Name() == "node_modules" || entry.Name() == ".git" || absPath == binDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if absPath, _ := filepath.Abs(path); buildRecords[absPath] {
+			return nil
+		}
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		_, _ = io.WriteString(hash, filepath.ToSlash(relPath)+"\x00")
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(hash, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,26 @@
+package build
+
+import (
+	"strings"
+)
+
+// cgoCompiler returns the given CC or CXX command for the given arch. `${arch}` is replaced with the
+// arch so that darwin universal and multi-arch builds can use a compiler per arch, EG: `${arch}-clang`.
+func cgoCompiler(compiler string, arch string) string {
+	return strings.ReplaceAll(compiler, "${arch}", arch)
+}
+
+// compiledArchs returns the archs that are compiled for the given options.
+// Universal binaries are made from amd64 and arm64 binaries.
+func compiledArchs(options *Options) []string {
+	var result []string
+	for _, arch := range strings.Split(options.Arch, ",") {
+		arch = strings.TrimSpace(arch)
+		if arch == "universal" {
+			result = append(result, "amd64", "arm64")
+			continue
+		}
+		result = append(result, arch)
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// checksumsFilename is the name of the checksums file written to the bin directory
+const checksumsFilename = "SHA256SUMS"
+
+// checksumFiles returns the files in the bin directory that the checksums file covers: the compiled
+// binaries and the generated files such as the .deb, AppImage or notarization zip.
+// Directories, such as the darwin .app bundle, are not included.
+func checksumFiles(options *Options) []string {
+	candidates := []string{options.CompiledBinary}
+	candidates = append(candidates, lo.Values(options.CompiledBinaries)...)
+	candidates = append(candidates, options.GeneratedArtifacts...)
+
+	var result []string
+	for _, filename := range lo.Uniq(candidates) {
+		if filename == "" || !fs.FileExists(filename) {
+			continue
+		}
+		relative, err := filepath.Rel(options.BinDirectory, filename)
+		if err != nil || strings.HasPrefix(relative, "..") {
+			continue
+		}
+		result = append(result, filename)
+	}
+	return result
+}
+
+// writeChecksums writes the SHA256SUMS file to the bin directory in the format `sha256sum -c` expects.
+// Entries of files from previous builds into the same directory are kept, so building several
+// targets produces a single file covering all of them. The file is signed if GPGSigningKey is set.
+func writeChecksums(options *Options) error {
+	checksumsFile := filepath.Join(options.BinDirectory, checksumsFilename)
+
+	checksums := map[string]string{}
+	if fs.FileExists(checksumsFile) {
+		existing, err := os.ReadFile(checksumsFile)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(existing), "\n") {
+			hash, filename, found := strings.Cut(line, "  ")
+			if found {
+				checksums[filename] = hash
+			}
+		}
+	}
+
+	for _, filename := range checksumFiles(options) {
+		hash, err := sha256File(filename)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(options.BinDirectory, filename)
+		if err != nil {
+			return err
+		}
+		checksums[filepath.ToSlash(relative)] = hash
+	}
+
+	filenames := lo.Keys(checksums)
+	sort.Strings(filenames)
+	var content strings.Builder
+	for _, filename := range filenames {
+		content.WriteString(checksums[filename] + "  " + filename + "\n")
+	}
+	if err := os.WriteFile(checksumsFile, []byte(content.String()), 0644); err != nil {
+		return err
+	}
+	options.addArtifact(checksumsFile)
+
+	if options.GPGSigningKey == "" {
+		return nil
+	}
+	if !shell.CommandExists("gpg") {
+		return fmt.Errorf("cannot sign %s: gpg not found on PATH", checksumsFilename)
+	}
+	signatureFile := checksumsFile + ".asc"
+	_, stderr, err := shell.RunCommandWithContext(options.buildContext(), options.BinDirectory, "gpg", "--batch", "--yes", "--local-user", options.GPGSigningKey, "--armor", "--detach-sign", "--output", signatureFile, checksumsFile)
+	if err != nil {
+		return fmt.Errorf("error signing %s: %w - %s", checksumsFilename, err, stderr)
+	}
+	options.addArtifact(signatureFile)
+	return nil
+}
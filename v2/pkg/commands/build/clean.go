@@ -0,0 +1,189 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/staticanalysis"
+)
+
+// Clean removes what Build generates in the project: the bin directory, a leftover Windows .syso
+// resource file and the placeholders created in empty embed directories. The generated wailsjs
+// bindings and runtime are only removed when CleanBindings is set, in case they have been edited.
+// The bin directory is backed up instead of removed when BackupBeforeClean is set.
+// Nothing needs to exist for Clean to succeed.
+func Clean(options *Options) error {
+	if options.ProjectData == nil {
+		return fmt.Errorf("cannot clean: no project data given")
+	}
+	projectData := options.ProjectData
+
+	cwd, err := options.workingDir()
+	if err != nil {
+		return err
+	}
+	resolveBinDirectory(options, cwd)
+	if err := removeBinDirectory(options); err != nil {
+		return err
+	}
+
+	sysoFile := filepath.Join(projectData.Path, projectData.Name+"-res.syso")
+	if err := os.Remove(sysoFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if placeholderName := options.embedPlaceholderName(); placeholderName != "" {
+		embedDetails, err := staticanalysis.GetEmbedDetails(projectData.Path)
+		if err != nil {
+			return err
+		}
+		for _, embedDetail := range embedDetails {
+			if embedDetail.IsFile {
+				continue
+			}
+			if err := removeEmbedPlaceholder(embedDetail.GetFullPath(), placeholderName); err != nil {
+				return err
+			}
+		}
+	}
+
+	if options.CleanBindings {
+		options.WailsJSDir = projectData.GetWailsJSDir()
+		for _, dir := range []string{
+			filepath.Join(bindingsOutputDir(options), "wailsjs", "go"),
+			filepath.Join(options.WailsJSDir, "wailsjs", "runtime"),
+		} {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+			// Only remove the wailsjs directory if nothing else is in it
+			wailsjsDir := filepath.Dir(dir)
+			if fs.DirExists(wailsjsDir) {
+				if entries, err := os.ReadDir(wailsjsDir); err == nil && len(entries) == 0 {
+					if err := os.Remove(wailsjsDir); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// binBackupTimeFormat is the format of the time in the names of bin directory backups.
+// It sorts in time order so the oldest backups can be found by name.
+const binBackupTimeFormat = "20060102-150405.000"
+
+// removeBinDirectory removes the bin directory or, if BackupBeforeClean is set, moves it to a
+// bin.bak-<time> directory next to it and prunes the backups beyond CleanBackupsToKeep.
+// Empty bin directories are removed rather than backed up.
+func removeBinDirectory(options *Options) error {
+	binDirectory := options.BinDirectory
+	if err := checkBinDirectoryRemovable(options); err != nil {
+		return err
+	}
+	if !options.BackupBeforeClean {
+		return os.RemoveAll(binDirectory)
+	}
+	entries, err := os.ReadDir(binDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(entries) == 0 {
+		return os.Remove(binDirectory)
+	}
+
+	backupPrefix := filepath.Base(binDirectory) + ".bak-"
+	backupDirectory := filepath.Join(filepath.Dir(binDirectory), backupPrefix+time.Now().Format(binBackupTimeFormat))
+	if err := os.Rename(binDirectory, backupDirectory); err != nil {
+		return fmt.Errorf("unable to back up the bin directory: %w", err)
+	}
+	if options.Logger != nil {
+		options.Logger.Println("  - Backed up the bin directory to '%s'", backupDirectory)
+	}
+
+	if options.CleanBackupsToKeep <= 0 {
+		return nil
+	}
+	siblings, err := os.ReadDir(filepath.Dir(binDirectory))
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, sibling := range siblings {
+		if sibling.IsDir() && strings.HasPrefix(sibling.Name(), backupPrefix) {
+			backups = append(backups, sibling.Name())
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > options.CleanBackupsToKeep {
+		if err := os.RemoveAll(filepath.Join(filepath.Dir(binDirectory), backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// checkBinDirectoryRemovable returns an error if removing the bin directory would remove the
+// project, the working directory or the filesystem root, such as when BinDirectory is "." or "/"
+func checkBinDirectoryRemovable(options *Options) error {
+	if options.BinDirectory == "" {
+		return fmt.Errorf("refusing to remove the bin directory: no bin directory given")
+	}
+	binDirectory, err := filepath.Abs(options.BinDirectory)
+	if err != nil {
+		return err
+	}
+	if binDirectory == filepath.Dir(binDirectory) {
+		return fmt.Errorf("refusing to remove the bin directory '%s': it is the filesystem root", options.BinDirectory)
+	}
+	var protected []struct{ dir, name string }
+	if options.ProjectData != nil && options.ProjectData.Path != "" {
+		if projectDir, err := filepath.Abs(options.ProjectData.Path); err == nil {
+			protected = append(protected, struct{ dir, name string }{projectDir, "the project directory"})
+		}
+	}
+	if cwd, err := options.workingDir(); err == nil {
+		protected = append(protected, struct{ dir, name string }{cwd, "the working directory"})
+	}
+	for _, p := range protected {
+		if isWithinDir(p.dir, binDirectory) {
+			return fmt.Errorf("refusing to remove the bin directory '%s': it contains %s", options.BinDirectory, p.name)
+		}
+	}
+	return nil
+}
+
+// removeEmbedPlaceholder removes the placeholder createEmbedDirectory creates in the given directory.
+// The placeholder is only removed if it is empty and the only file in the directory, so that
+// files with the same name that weren't created by the build are kept.
+func removeEmbedPlaceholder(fullPath string, placeholderName string) error {
+	entries, err := os.ReadDir(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(entries) != 1 || entries[0].Name() != placeholderName {
+		return nil
+	}
+	info, err := entries[0].Info()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() != 0 {
+		return nil
+	}
+	return os.Remove(filepath.Join(fullPath, placeholderName))
+}
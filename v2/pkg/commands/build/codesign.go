@@ -0,0 +1,116 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// signMacBundle signs the packaged .app bundle with codesign using the MacSigningIdentity.
+// Signing is skipped if no identity has been given.
+func signMacBundle(options *Options) error {
+	outputLogger := options.Logger
+
+	if options.MacSigningIdentity == "" {
+		if options.MacEntitlementsFile != "" || options.verbosity() == VERBOSE {
+			outputLogger.Println("Warning: No macOS signing identity given. Skipping code signing.")
+		}
+		return nil
+	}
+
+	if !shell.CommandExists("codesign") {
+		return fmt.Errorf("cannot sign application: codesign not found. Please install the Xcode command line tools")
+	}
+
+	args := []string{"--deep", "--force", "--options", "runtime", "--sign", options.MacSigningIdentity}
+	if options.MacEntitlementsFile != "" {
+		entitlementsFile := options.MacEntitlementsFile
+		if !filepath.IsAbs(entitlementsFile) {
+			entitlementsFile = filepath.Join(options.ProjectData.Path, entitlementsFile)
+		}
+		args = append(args, "--entitlements", entitlementsFile)
+	}
+	args = append(args, options.CompiledBundle)
+
+	outputLogger.Print("  - Signing application: ")
+	if options.verbosity() == VERBOSE {
+		outputLogger.Println("")
+		outputLogger.Println("  Sign command: codesign %s", strings.Join(args, " "))
+	}
+	_, stderr, err := shell.RunCommandWithContext(options.buildContext(), options.BinDirectory, "codesign", args...)
+	if err != nil {
+		return fmt.Errorf("codesign failed: %s\n%s", err.Error(), stderr)
+	}
+	outputLogger.Println("Done.")
+
+	if options.NotarizeProfile != "" {
+		return notarizeMacBundle(options)
+	}
+
+	return nil
+}
+
+// notarySubmission is the JSON output of `notarytool submit`
+type notarySubmission struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// notarizeMacBundle submits the signed .app bundle for notarization using the NotarizeProfile
+// keychain profile, waits for the result and staples the ticket to the bundle
+func notarizeMacBundle(options *Options) error {
+	outputLogger := options.Logger
+	ctx := options.buildContext()
+
+	if !shell.CommandExists("xcrun") {
+		return fmt.Errorf("cannot notarize application: xcrun not found. Please install the Xcode command line tools")
+	}
+
+	outputLogger.Print("  - Notarizing application: ")
+
+	// notarytool needs the bundle as a zip
+	zipFile := strings.TrimSuffix(options.CompiledBundle, ".app") + ".zip"
+	_, stderr, err := shell.RunCommandWithContext(ctx, options.BinDirectory, "ditto", "-c", "-k", "--keepParent", options.CompiledBundle, zipFile)
+	if err != nil {
+		return fmt.Errorf("unable to zip application for notarization: %s\n%s", err.Error(), stderr)
+	}
+	if options.KeepAssets {
+		options.addArtifact(zipFile)
+	} else {
+		defer func() {
+			_ = fs.DeleteFile(zipFile)
+		}()
+	}
+
+	stdout, stderr, err := shell.RunCommandWithContext(ctx, options.BinDirectory, "xcrun", "notarytool", "submit", zipFile, "--keychain-profile", options.NotarizeProfile, "--wait", "--output-format", "json")
+	var submission notarySubmission
+	if jsonErr := json.Unmarshal([]byte(stdout), &submission); jsonErr != nil {
+		if err != nil {
+			return fmt.Errorf("notarytool failed: %s\n%s", err.Error(), stderr)
+		}
+		return fmt.Errorf("unable to parse notarytool output: %s\n%s", jsonErr.Error(), stdout)
+	}
+	outputLogger.Println("")
+	outputLogger.Println("  Submission ID: %s", submission.ID)
+	outputLogger.Println("  Status: %s", submission.Status)
+
+	if submission.Status != "Accepted" {
+		notaryLog, _, _ := shell.RunCommandWithContext(ctx, options.BinDirectory, "xcrun", "notarytool", "log", submission.ID, "--keychain-profile", options.NotarizeProfile)
+		return fmt.Errorf("notarization of submission %s failed with status '%s': %s\nNotarization log:\n%s", submission.ID, submission.Status, submission.Message, notaryLog)
+	}
+
+	outputLogger.Print("  - Stapling notarization ticket: ")
+	_, stderr, err = shell.RunCommandWithContext(ctx, options.BinDirectory, "xcrun", "stapler", "staple", options.CompiledBundle)
+	if err != nil {
+		return fmt.Errorf("stapler failed: %s\n%s", err.Error(), stderr)
+	}
+	outputLogger.Println("Done.")
+
+	return nil
+}
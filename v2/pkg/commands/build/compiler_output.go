@@ -0,0 +1,36 @@
+package build
+
+import (
+	"regexp"
+	"strings"
+)
+
+// compilerWarningRegex matches the warnings of the tools used by the go toolchain.
+// The Go compiler only reports errors, so warnings come from the C compiler and linker
+// used by cgo, EG: `ld: warning: ...` or `main.c:10:2: warning: unused variable 'x'`.
+var compilerWarningRegex = regexp.MustCompile(`(?i)\bwarning:`)
+
+// splitCompilerOutput separates the warnings in the compiler's stderr from the rest of it.
+// The `# package` lines the go toolchain prints before a package's output are only kept
+// in the rest of the output if that package reported something other than warnings.
+func splitCompilerOutput(stderr string) (warnings []string, output []string) {
+	pendingHeader := ""
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case strings.HasPrefix(line, "# "):
+			pendingHeader = line
+		case compilerWarningRegex.MatchString(line):
+			warnings = append(warnings, line)
+		default:
+			if pendingHeader != "" {
+				output = append(output, pendingHeader)
+				pendingHeader = ""
+			}
+			output = append(output, line)
+		}
+	}
+	return warnings, output
+}
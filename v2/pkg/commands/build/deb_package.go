@@ -0,0 +1,173 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/shell"
+	"github.com/wailsapp/wails/v2/pkg/buildassets"
+)
+
+// Supported values for Options.LinuxPackageFormat
+const (
+	LinuxPackageAppImage = "appimage"
+	LinuxPackageDeb      = "deb"
+)
+
+// debianArchs maps Go architectures to Debian architectures
+var debianArchs = map[string]string{
+	"amd64": "amd64",
+	"arm64": "arm64",
+	"arm":   "armhf",
+	"386":   "i386",
+}
+
+var invalidDebianPackageChars = regexp.MustCompile(`[^a-z0-9+.-]`)
+
+// packageDeb creates a .deb package of the compiled binary in the bin directory
+func packageDeb(options *Options) error {
+	if !shell.CommandExists("dpkg-deb") {
+		return fmt.Errorf("cannot create .deb package: dpkg-deb not found on PATH")
+	}
+	debianArch, supported := debianArchs[options.Arch]
+	if !supported {
+		return fmt.Errorf("cannot create .deb package: arch '%s' not supported", options.Arch)
+	}
+
+	projectData := options.ProjectData
+	name := projectData.Name
+	packageName := invalidDebianPackageChars.ReplaceAllString(strings.ToLower(name), "-")
+	version := projectData.Info.ProductVersion
+
+	packageRoot := filepath.Join(options.BinDirectory, fmt.Sprintf("%s_%s_%s", packageName, version, debianArch))
+	_ = os.RemoveAll(packageRoot)
+	if !options.KeepAssets {
+		defer func() {
+			_ = os.RemoveAll(packageRoot)
+		}()
+	} else {
+		options.addArtifact(packageRoot)
+	}
+
+	// Binary
+	binDir := filepath.Join(packageRoot, "usr", "bin")
+	if err := fs.MkDirs(binDir, 0755); err != nil {
+		return err
+	}
+	packedBinary := filepath.Join(binDir, name)
+	if err := fs.CopyFile(options.CompiledBinary, packedBinary); err != nil {
+		return err
+	}
+
+	// Desktop file + icon
+	applicationsDir := filepath.Join(packageRoot, "usr", "share", "applications")
+	if err := fs.MkDirs(applicationsDir, 0755); err != nil {
+		return err
+	}
+	desktopFile, err := buildassets.ReadFileWithProjectData(projectData, "linux/app.desktop")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(applicationsDir, name+".desktop"), desktopFile, 0644); err != nil {
+		return err
+	}
+	pixmapsDir := filepath.Join(packageRoot, "usr", "share", "pixmaps")
+	if err := fs.MkDirs(pixmapsDir, 0755); err != nil {
+		return err
+	}
+	appIcon, err := readPNGIcon(options, "linux")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(pixmapsDir, name+".png"), appIcon, 0644); err != nil {
+		return err
+	}
+
+	// Control file
+	debianDir := filepath.Join(packageRoot, "DEBIAN")
+	if err := fs.MkDirs(debianDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(debianDir, "control"), debianControlFile(options, packageName, debianArch), 0644); err != nil {
+		return err
+	}
+
+	if err := setDebFileModes(options, packageRoot, packedBinary); err != nil {
+		return err
+	}
+
+	target := packageRoot + ".deb"
+	var stde bytes.Buffer
+	args := []string{"--build", packageRoot, target}
+	if options.Reproducible {
+		args = append([]string{"--root-owner-group"}, args...)
+	}
+	cmd := shell.CreateCommand(options.BinDirectory, "dpkg-deb", args...)
+	cmd.Stderr = &stde
+	if options.Reproducible {
+		cmd.Env = append(os.Environ(), fmt.Sprintf("SOURCE_DATE_EPOCH=%d", options.sourceDate.Unix()))
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error creating .deb package: %w - %s", err, stde.String())
+	}
+
+	options.CompiledBundle = target
+	return nil
+}
+
+// setDebFileModes sets the modes of everything in the package so they don't depend on the umask:
+// 0755 for directories and the binary, 0644 for other files, unless LinuxFileModes says otherwise.
+// The binary is always executable.
+func setDebFileModes(options *Options, packageRoot string, packedBinary string) error {
+	return filepath.Walk(packageRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(packageRoot, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		var mode os.FileMode
+		switch {
+		case info.IsDir():
+			mode = linuxFileMode(options, relativePath, 0755)
+		case path == packedBinary:
+			mode = linuxFileMode(options, relativePath, 0755) | executableMode
+		default:
+			mode = linuxFileMode(options, relativePath, 0644)
+		}
+		return os.Chmod(path, mode)
+	})
+}
+
+// debianControlFile generates the DEBIAN/control file for the project
+func debianControlFile(options *Options, packageName string, debianArch string) []byte {
+	projectData := options.ProjectData
+
+	description := projectData.Info.ProductName
+	if projectData.Info.Comments != nil && *projectData.Info.Comments != "" {
+		description = *projectData.Info.Comments
+	}
+
+	maintainer := projectData.Author.Name
+	if maintainer == "" {
+		maintainer = projectData.Info.CompanyName
+	}
+	if projectData.Author.Email != "" {
+		maintainer += " <" + projectData.Author.Email + ">"
+	}
+
+	var control strings.Builder
+	control.WriteString("Package: " + packageName + "\n")
+	control.WriteString("Version: " + projectData.Info.ProductVersion + "\n")
+	control.WriteString("Architecture: " + debianArch + "\n")
+	control.WriteString("Maintainer: " + maintainer + "\n")
+	control.WriteString("Description: " + description + "\n")
+	return []byte(control.String())
+}
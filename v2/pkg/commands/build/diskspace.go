@@ -0,0 +1,45 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultMinFreeDiskBytes is the free disk space the CLI requires before starting a build
+const DefaultMinFreeDiskBytes = 512 * 1024 * 1024
+
+// checkFreeDiskSpace returns an error if the volume of the bin directory has less than
+// MinFreeDiskBytes available. The check is skipped if MinFreeDiskBytes is 0.
+func checkFreeDiskSpace(options *Options) error {
+	if options.MinFreeDiskBytes == 0 {
+		return nil
+	}
+
+	// The bin directory may not have been created yet, so check its nearest existing parent
+	dir := options.BinDirectory
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+
+	available, err := freeDiskSpace(dir)
+	if err != nil {
+		return fmt.Errorf("unable to determine the free disk space in '%s': %w", dir, err)
+	}
+	if available < options.MinFreeDiskBytes {
+		return fmt.Errorf("not enough free disk space to build in '%s': %s available, %s required", options.BinDirectory, formatBytes(available), formatBytes(options.MinFreeDiskBytes))
+	}
+	return nil
+}
+
+// formatBytes returns the given number of bytes in MB
+func formatBytes(bytes uint64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package build
+
+import "golang.org/x/sys/unix"
+
+// freeDiskSpace returns the number of bytes available to the user on the volume of the given directory
+func freeDiskSpace(dir string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package build
+
+import "golang.org/x/sys/windows"
+
+// freeDiskSpace returns the number of bytes available to the user on the volume of the given directory
+func freeDiskSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
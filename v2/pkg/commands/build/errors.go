@@ -0,0 +1,73 @@
+package build
+
+// The errors returned by Build for failures in each part of the build. Their messages are those
+// of the error they wrap, so they only add a way to tell the failures apart with errors.As.
+
+// BindingsError is returned when the bindings can't be generated or, in check only mode, are out of date
+type BindingsError struct {
+	Err error
+}
+
+func (e *BindingsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BindingsError) Unwrap() error {
+	return e.Err
+}
+
+// FrontendBuildError is returned when installing the frontend dependencies or building the frontend fails
+type FrontendBuildError struct {
+	Err error
+}
+
+func (e *FrontendBuildError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FrontendBuildError) Unwrap() error {
+	return e.Err
+}
+
+// CompileError is returned when the compiler fails. Output holds what the compiler wrote to stderr
+type CompileError struct {
+	Err    error
+	Output string
+}
+
+func (e *CompileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// PackagingError is returned when packaging or signing the compiled application fails
+type PackagingError struct {
+	Err error
+}
+
+func (e *PackagingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PackagingError) Unwrap() error {
+	return e.Err
+}
+
+// HookError is returned when a build hook fails. Identifier is the platform of the hook, EG: darwin/*,
+// and is empty for the global hooks. Phase is when it runs, EG: pre, pre-compile, post or global post
+type HookError struct {
+	Identifier string
+	Phase      string
+	Err        error
+}
+
+func (e *HookError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
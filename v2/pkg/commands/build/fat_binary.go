@@ -0,0 +1,102 @@
+package build
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// fatArchAlign is the power of 2 each slice of a fat binary is aligned to. arm64 macOS needs 16KB pages
+const fatArchAlign = 14
+
+// fatSlice is a Mach-O binary to go in a fat binary
+type fatSlice struct {
+	filename string
+	cpu      macho.Cpu
+	subCpu   uint32
+	size     int64
+	offset   int64
+}
+
+// writeFatBinary combines the given Mach-O binaries into a universal binary, as `lipo -create` does.
+// It allows universal binaries to be created where lipo isn't available, EG: on Linux
+func writeFatBinary(outputFile string, inputFiles ...string) error {
+	slices := make([]*fatSlice, 0, len(inputFiles))
+	offset := int64(8 + 20*len(inputFiles))
+	for _, filename := range inputFiles {
+		slice, err := readFatSlice(filename)
+		if err != nil {
+			return err
+		}
+		for _, other := range slices {
+			if other.cpu == slice.cpu {
+				return fmt.Errorf("'%s' and '%s' are both %s binaries", other.filename, slice.filename, slice.cpu)
+			}
+		}
+		offset = (offset + 1<<fatArchAlign - 1) &^ (1<<fatArchAlign - 1)
+		slice.offset = offset
+		offset += slice.size
+		if offset > math.MaxUint32 {
+			return fmt.Errorf("universal binaries over 4GB are not supported")
+		}
+		slices = append(slices, slice)
+	}
+
+	output, err := os.OpenFile(outputFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	if err := writeFatSlices(output, slices); err != nil {
+		_ = output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// readFatSlice reads the architecture and size of the given Mach-O binary
+func readFatSlice(filename string) (*fatSlice, error) {
+	file, err := macho.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a Mach-O binary: %w", filename, err)
+	}
+	defer file.Close()
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &fatSlice{
+		filename: filename,
+		cpu:      file.Cpu,
+		subCpu:   file.SubCpu,
+		size:     info.Size(),
+	}, nil
+}
+
+// writeFatSlices writes the fat header, one fat_arch per slice and the padded slices to the given writer
+func writeFatSlices(output io.WriteSeeker, slices []*fatSlice) error {
+	header := []uint32{macho.MagicFat, uint32(len(slices))}
+	for _, slice := range slices {
+		header = append(header, uint32(slice.cpu), slice.subCpu, uint32(slice.offset), uint32(slice.size), fatArchAlign)
+	}
+	if err := binary.Write(output, binary.BigEndian, header); err != nil {
+		return err
+	}
+	for _, slice := range slices {
+		if _, err := output.Seek(slice.offset, io.SeekStart); err != nil {
+			return err
+		}
+		input, err := os.Open(slice.filename)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(output, input)
+		_ = input.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
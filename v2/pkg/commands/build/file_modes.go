@@ -0,0 +1,60 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// executableMode is added to the mode of the compiled binary in packages so it can always be run
+const executableMode os.FileMode = 0111
+
+// parseFileMode parses an octal file mode, EG: 0644
+func parseFileMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0777 {
+		return 0, fmt.Errorf("invalid file mode '%s': must be an octal permission such as 0644", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// linuxFileMode returns the mode of the given file in a Linux package, using the first matching
+// pattern of the LinuxFileModes option in sorted order. The path is relative to the package root,
+// EG: usr/share/applications/myapp.desktop in a deb or myapp.desktop in a tarball.
+func linuxFileMode(options *Options, filePath string, defaultMode os.FileMode) os.FileMode {
+	patterns := make([]string, 0, len(options.LinuxFileModes))
+	for pattern := range options.LinuxFileModes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, filePath); !matched {
+			continue
+		}
+		if mode, err := parseFileMode(options.LinuxFileModes[pattern]); err == nil {
+			return mode
+		}
+	}
+	return defaultMode
+}
+
+// validateLinuxFileModes returns the problems with the patterns and modes of the LinuxFileModes option
+func validateLinuxFileModes(options *Options) []string {
+	var problems []string
+	patterns := make([]string, 0, len(options.LinuxFileModes))
+	for pattern := range options.LinuxFileModes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid Linux file mode pattern '%s': %s", pattern, err))
+		}
+		if _, err := parseFileMode(options.LinuxFileModes[pattern]); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid Linux file mode for '%s': %s", pattern, err))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,126 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/staticanalysis"
+)
+
+// frontendArchiveExtensions lists the extensions of the archives FrontendArchive embeds, longest first
+var frontendArchiveExtensions = []string{".tar.gz", ".tgz", ".tar"}
+
+// frontendArchiveEmbed returns the archive embedded by the project, EG: //go:embed frontend/dist.tar.gz,
+// and the directory archived into it: the archive path without its extension, EG: frontend/dist
+func frontendArchiveEmbed(options *Options) (archive string, sourceDir string, err error) {
+	embedDetails, err := staticanalysis.GetEmbedDetails(options.ProjectData.Path)
+	if err != nil {
+		return "", "", err
+	}
+	return selectFrontendArchive(embedDetails)
+}
+
+// selectFrontendArchive returns the only archive of the given embeds and the directory archived into it
+func selectFrontendArchive(embedDetails []*staticanalysis.EmbedDetails) (archive string, sourceDir string, err error) {
+	var archives []string
+	for _, embedDetail := range embedDetails {
+		if !embedDetail.IsFile || frontendArchiveExtension(embedDetail.EmbedPath) == "" {
+			continue
+		}
+		archives = append(archives, embedDetail.GetFullPath())
+	}
+	switch len(archives) {
+	case 0:
+		return "", "", fmt.Errorf("the project embeds no .tar, .tar.gz or .tgz archive to put the frontend in, EG: //go:embed frontend/dist.tar.gz")
+	case 1:
+		return archives[0], strings.TrimSuffix(archives[0], frontendArchiveExtension(archives[0])), nil
+	default:
+		return "", "", fmt.Errorf("cannot tell which embedded archive the frontend goes in: %s", strings.Join(archives, ", "))
+	}
+}
+
+// frontendArchiveExtension returns the archive extension of the given path, or "" if it isn't an archive
+func frontendArchiveExtension(path string) string {
+	for _, extension := range frontendArchiveExtensions {
+		if strings.HasSuffix(path, extension) {
+			return extension
+		}
+	}
+	return ""
+}
+
+// writeFrontendArchive writes the files of the source directory to the given tar archive, gzipped for
+// .tar.gz and .tgz. Paths are relative to the source directory. A missing directory gives an empty
+// archive, so the application can be compiled for its bindings before the frontend is built.
+func writeFrontendArchive(options *Options, archive string, sourceDir string) error {
+	output, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	var writer io.Writer = output
+	var gzipWriter *gzip.Writer
+	if extension := frontendArchiveExtension(archive); extension == ".tar.gz" || extension == ".tgz" {
+		gzipWriter = gzip.NewWriter(output)
+		writer = gzipWriter
+	}
+	tarWriter := tar.NewWriter(writer)
+
+	modTime := options.buildTimestamp()
+	if _, err := os.Stat(sourceDir); err == nil {
+		err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || path == sourceDir {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				return nil
+			}
+			relativePath, err := filepath.Rel(sourceDir, path)
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(relativePath)
+			header.ModTime = modTime
+			header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+			if info.IsDir() {
+				header.Name += "/"
+			}
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(tarWriter, file)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error archiving the frontend: %w", err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("error archiving the frontend: %w", err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return fmt.Errorf("error archiving the frontend: %w", err)
+		}
+	}
+	return output.Close()
+}
@@ -0,0 +1,35 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/staticanalysis"
+)
+
+// checkFrontendAssets returns an error if the frontend's embedded directory has no index.html, which
+// would compile an application showing a blank window. See checkFrontendEmbedDir
+func checkFrontendAssets(options *Options) error {
+	embedDetails, err := staticanalysis.GetEmbedDetails(options.ProjectData.Path)
+	if err != nil {
+		return err
+	}
+	return checkFrontendEmbedDir(embedDetails, options.ProjectData.GetFrontendDir())
+}
+
+// checkFrontendEmbedDir checks the embedded directory the built frontend goes in holds an index.html.
+// Nothing is checked when that directory can't be told from the embeds
+func checkFrontendEmbedDir(embedDetails []*staticanalysis.EmbedDetails, frontendDir string) error {
+	embedDir, err := selectFrontendEmbedDir(embedDetails, frontendDir)
+	if err != nil {
+		return nil
+	}
+	if fs.FileExists(filepath.Join(embedDir, "index.html")) {
+		return nil
+	}
+	if !fs.DirExists(embedDir) {
+		return fmt.Errorf("the embedded frontend directory '%s' does not exist: build the frontend or set AllowEmptyEmbeds (-allowemptyembeds)", embedDir)
+	}
+	return fmt.Errorf("the embedded frontend directory '%s' has no index.html and the application would show a blank window: build the frontend or set AllowEmptyEmbeds (-allowemptyembeds)", embedDir)
+}
@@ -0,0 +1,119 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/leaanthony/slicer"
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// frontendHashFile is the file in the project build directory that holds the hash of the last frontend build
+const frontendHashFile = "frontend.hash"
+
+// defaultFrontendHashIgnore are the directories in the frontend that are not hashed by default.
+// node_modules is always ignored.
+var defaultFrontendHashIgnore = []string{"dist", "build"}
+
+// frontendRetryBackoff is the delay before retrying a failed frontend build. It increases with each attempt
+var frontendRetryBackoff = 2 * time.Second
+
+// buildFrontend builds the frontend. If SkipFrontendIfUnchanged is set and the frontend
+// sources are the same as the previous build, the build is skipped.
+func buildFrontend(builder Builder, options *Options) error {
+	if !options.SkipFrontendIfUnchanged {
+		return buildFrontendWithRetries(builder, options)
+	}
+
+	hashFile := filepath.Join(options.ProjectData.GetBuildDir(), frontendHashFile)
+	hash, err := frontendSourceHash(options)
+	if err != nil {
+		return err
+	}
+	if fs.FileExists(hashFile) && fs.MustLoadString(hashFile) == hash {
+		options.Logger.Println("  - Frontend unchanged. Skipping.")
+		return nil
+	}
+
+	err = buildFrontendWithRetries(builder, options)
+	if err != nil {
+		return err
+	}
+	err = fs.MkDirs(filepath.Dir(hashFile), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(hashFile, []byte(hash), 0644)
+}
+
+// buildFrontendWithRetries builds the frontend, retrying up to FrontendBuildRetries times
+// if a command exits with a non-zero status
+func buildFrontendWithRetries(builder Builder, options *Options) error {
+	ctx := options.buildContext()
+	for attempt := 1; ; attempt++ {
+		err := builder.BuildFrontend(options.Logger)
+		var exitErr *exec.ExitError
+		if err == nil || attempt > options.FrontendBuildRetries || ctx.Err() != nil || !errors.As(err, &exitErr) {
+			return err
+		}
+
+		backoff := time.Duration(attempt) * frontendRetryBackoff
+		options.Logger.Println("  - Frontend build failed (attempt %d of %d): %s. Retrying in %s.", attempt, options.FrontendBuildRetries+1, err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// frontendSourceHash returns a hash of the frontend sources and the commands used to build them
+func frontendSourceHash(options *Options) (string, error) {
+	projectData := options.ProjectData
+	ignore := slicer.String(options.FrontendHashIgnore)
+	if options.FrontendHashIgnore == nil {
+		ignore.AddSlice(defaultFrontendHashIgnore)
+	}
+	ignore.Add("node_modules")
+
+	hash := sha256.New()
+	for _, command := range []string{projectData.OutputType, options.FrontendPackageManager, projectData.InstallCommand, projectData.GetBuildCommand(options.Platform), projectData.GetDevInstallerCommand(), projectData.GetDevBuildCommand()} {
+		_, _ = io.WriteString(hash, command+"\x00")
+	}
+
+	frontendDir := projectData.GetFrontendDir()
+	err := filepath.WalkDir(frontendDir, func(path string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != frontendDir && ignore.Contains(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(frontendDir, path)
+		if err != nil {
+			return err
+		}
+		_, _ = io.WriteString(hash, filepath.ToSlash(relPath)+"\x00")
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(hash, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,115 @@
+package build
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// compatibleGarbleVersions maps Go releases to the garble releases that support them.
+// Garble generally only supports the latest one or two Go releases.
+var compatibleGarbleVersions = map[string]string{
+	"1.19": ">= 0.8.0, < 0.9.0",
+	"1.20": ">= 0.9.0, < 0.11.0",
+	"1.21": ">= 0.10.1, < 0.12.0",
+	"1.22": ">= 0.12.0, < 0.14.0",
+	"1.23": ">= 0.13.0, < 0.15.0",
+	"1.24": ">= 0.14.0",
+}
+
+var goVersionRegex = regexp.MustCompile(`go(\d+\.\d+)`)
+
+// checkGarble ensures garble is installed before we start building and that it
+// supports the version of Go used to compile the application
+func checkGarble(options *Options) error {
+	garblePath, err := exec.LookPath("garble")
+	if err != nil {
+		return fmt.Errorf("the 'garble' command was not found. Please install it with `go install mvdan.cc/garble@latest`")
+	}
+
+	stdout, _, err := shell.RunCommandWithContext(options.buildContext(), ".", garblePath, "version")
+	if err != nil {
+		return fmt.Errorf("unable to determine garble version: %w", err)
+	}
+	// The first line of the output is in the form `mvdan.cc/garble v0.10.1`
+	firstLine := strings.TrimSpace(strings.SplitN(stdout, "\n", 2)[0])
+	versionString := strings.TrimPrefix(firstLine[strings.LastIndex(firstLine, " ")+1:], "v")
+	version, err := semver.NewVersion(versionString)
+	if err != nil {
+		options.Logger.Println("Warning: unable to parse garble version '%s'. Unable to check it supports your version of Go", firstLine)
+		return nil
+	}
+
+	// Parse the `go version` output. EG: `go version go1.21.3 linux/amd64`
+	stdout, err = goVersion(options)
+	if err != nil {
+		return fmt.Errorf("unable to determine Go version: %w", err)
+	}
+	match := goVersionRegex.FindStringSubmatch(stdout)
+	if match == nil {
+		options.Logger.Println("Warning: unable to parse Go version '%s'. Unable to check garble supports it", strings.TrimSpace(stdout))
+		return nil
+	}
+	goRelease := match[1]
+
+	compatible, known := compatibleGarbleVersions[goRelease]
+	if !known {
+		options.Logger.Println("Warning: unable to check garble %s supports Go %s. If the build fails, please update garble with `go install mvdan.cc/garble@latest`", version, goRelease)
+		return nil
+	}
+	constraint, err := semver.NewConstraint(compatible)
+	if err != nil {
+		return err
+	}
+	if !constraint.Check(version) {
+		return fmt.Errorf("garble %s does not support Go %s (requires garble %s). Please install a compatible version, EG: `go install mvdan.cc/garble@latest`", version, goRelease, compatible)
+	}
+	return nil
+}
+
+// garblePackages returns the GOGARBLE value that obfuscates the given modules except the excluded ones.
+// Garble has no way to exclude packages: GOGARBLE lists the module path prefixes it obfuscates,
+// so exclusions work on whole modules. An excluded path inside a module that is not excluded is an error.
+func garblePackages(modules []string, exclude []string) (string, error) {
+	isExcluded := func(path string) bool {
+		for _, excluded := range exclude {
+			if path == excluded || strings.HasPrefix(path, excluded+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, excluded := range exclude {
+		for _, module := range modules {
+			if strings.HasPrefix(excluded, module+"/") && !isExcluded(module) {
+				return "", fmt.Errorf("cannot exclude '%s' from obfuscation: garble can only exclude whole modules. Please exclude '%s' instead", excluded, module)
+			}
+		}
+	}
+
+	var result []string
+	for _, module := range modules {
+		if !isExcluded(module) {
+			result = append(result, module)
+		}
+	}
+	return strings.Join(result, ","), nil
+}
+
+// garbleEnv returns the GOGARBLE value for the project, listing every module it uses except
+// those in ObfuscationExclude. Empty if nothing is excluded, so garble's default is used.
+func garbleEnv(options *Options) (string, error) {
+	if len(options.ObfuscationExclude) == 0 {
+		return "", nil
+	}
+	stdout, stderr, err := shell.RunCommandWithContext(options.buildContext(), options.ProjectData.Path, options.Compiler, "list", "-m", "-f", "{{.Path}}", "all")
+	if err != nil {
+		return "", fmt.Errorf("unable to list the project's modules: %w - %s", err, stderr)
+	}
+	return garblePackages(strings.Fields(stdout), options.ObfuscationExclude)
+}
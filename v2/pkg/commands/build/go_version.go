@@ -0,0 +1,61 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/goversion"
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// goReleaseRegex extracts the release, patch included, from the `go version` output. EG: 1.21.3
+var goReleaseRegex = regexp.MustCompile(`go(\d+\.\d+(?:\.\d+)?)`)
+
+// goVersion returns the `go version` output of the compiler. It runs in the project directory so it
+// reports the toolchain the build uses when the project's go.mod selects one
+func goVersion(options *Options) (string, error) {
+	dir := "."
+	if options.ProjectData != nil {
+		dir = options.ProjectData.Path
+	}
+	stdout, _, err := shell.RunCommandWithContext(options.buildContext(), dir, options.Compiler, "version")
+	return stdout, err
+}
+
+// minGoVersion returns the oldest Go the project may be compiled with: MinGoVersion or, if none is
+// given, the oldest Go Wails supports
+func minGoVersion(options *Options) string {
+	minimum, _ := lo.Coalesce(options.MinGoVersion, goversion.MinRequirement)
+	return minimum
+}
+
+// checkGoVersion returns an error if the compiler is older than the minimum Go version, which would
+// otherwise fail with confusing compiler errors
+func checkGoVersion(options *Options) error {
+	minimum, err := semver.NewVersion(minGoVersion(options))
+	if err != nil {
+		return fmt.Errorf("invalid minimum Go version '%s': %w", minGoVersion(options), err)
+	}
+
+	// Parse the `go version` output. EG: `go version go1.21.3 linux/amd64`
+	stdout, err := goVersion(options)
+	if err != nil {
+		return fmt.Errorf("unable to determine Go version: %w", err)
+	}
+	match := goReleaseRegex.FindStringSubmatch(stdout)
+	if match == nil {
+		options.Logger.Println("Warning: unable to parse Go version '%s'. Unable to check it is Go %s or later", strings.TrimSpace(stdout), minimum)
+		return nil
+	}
+	version, err := semver.NewVersion(match[1])
+	if err != nil {
+		return nil
+	}
+	if version.LessThan(minimum) {
+		return fmt.Errorf("this project needs Go %s or later but the compiler is Go %s. Please upgrade Go: https://go.dev/dl/", minGoVersion(options), match[1])
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// applyGoEnv updates the given environment with the variables the go commands need for the given options.
+// Offline builds set GOPROXY=off so any step that needs to download a module fails immediately.
+// Vendored builds use -mod=vendor, even when offline, as the vendor directory needs no network.
+// Reproducible builds don't inherit GOFLAGS.
+func applyGoEnv(options *Options, env []string) []string {
+	if options.Reproducible {
+		env = upsertEnv(env, "GOFLAGS", func(v string) string {
+			return ""
+		})
+	}
+	if options.Offline {
+		env = upsertEnv(env, "GOPROXY", func(v string) string {
+			return "off"
+		})
+	}
+	if options.UseVendor || options.Offline {
+		mode := "mod"
+		if options.UseVendor {
+			mode = "vendor"
+		}
+		env = upsertEnv(env, "GOFLAGS", func(v string) string {
+			return setGoFlag(v, "-mod", mode)
+		})
+	}
+	return env
+}
+
+// goEnvChanges returns the variables applyGoEnv adds to or changes in the inherited environment, in the form
+// KEY=value. It is for commands that are run with the inherited environment plus the given variables.
+func goEnvChanges(options *Options) []string {
+	inherited := os.Environ()
+	return lo.Without(applyGoEnv(options, inherited), inherited...)
+}
+
+// checkVendor ensures the project has a vendor directory that is consistent with its go.mod.
+// The go toolchain reports any inconsistency when loading the project's packages from it.
+func checkVendor(options *Options) error {
+	projectDir := options.ProjectData.Path
+	if !fs.FileExists(filepath.Join(projectDir, "vendor", "modules.txt")) {
+		return fmt.Errorf("cannot build with the vendor directory: '%s' has no vendor/modules.txt. Please run `go mod vendor`", projectDir)
+	}
+	cmd := exec.CommandContext(options.buildContext(), options.Compiler, "list", "-mod=vendor", "./...")
+	cmd.Dir = projectDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("the vendor directory is not consistent with go.mod. Please run `go mod vendor`: %w - %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// setGoFlag sets the given flag in a GOFLAGS value, replacing any existing value of it
+func setGoFlag(goflags string, flag string, value string) string {
+	result := []string{}
+	for _, existing := range strings.Fields(goflags) {
+		if existing == flag || strings.HasPrefix(existing, flag+"=") {
+			continue
+		}
+		result = append(result, existing)
+	}
+	return strings.Join(append(result, flag+"="+value), " ")
+}
+
+// offlineInstallCommand returns the given frontend install command with the flag that stops the
+// package manager from using the network. An error is returned for package managers that can't.
+func offlineInstallCommand(command string) (string, error) {
+	args := strings.Split(command, " ")
+	switch args[0] {
+	case "npm", "pnpm", "yarn":
+		return command + " --offline", nil
+	case "bun":
+		return "", fmt.Errorf("bun cannot install the frontend dependencies offline. Please install them before building or use another package manager")
+	}
+	return command, nil
+}
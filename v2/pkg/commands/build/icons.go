@@ -0,0 +1,105 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leaanthony/winicon"
+	"github.com/samber/lo"
+	"github.com/tc-hib/winres"
+	"github.com/wailsapp/wails/v2/pkg/buildassets"
+)
+
+// iconFormats lists the extensions accepted for the icon file of each platform.
+// PNG icons are converted to the platform's native format when packaging.
+var iconFormats = map[string][]string{
+	"darwin":  {".icns", ".png"},
+	"windows": {".ico", ".png"},
+	"linux":   {".png"},
+}
+
+// platformIconFile returns the full path of the icon file given for the platform or "" if
+// the project's default icons are used. FreeBSD uses the Linux icon.
+func platformIconFile(options *Options, platform string) string {
+	var iconFile string
+	switch platform {
+	case "darwin":
+		iconFile = options.MacIconFile
+	case "windows":
+		iconFile = options.WindowsIconFile
+	case "linux", "freebsd":
+		iconFile = options.LinuxIconFile
+	}
+	if iconFile == "" || filepath.IsAbs(iconFile) {
+		return iconFile
+	}
+	return filepath.Join(options.ProjectData.Path, iconFile)
+}
+
+// readPNGIcon returns the PNG icon of the platform: its icon file if that is a PNG,
+// otherwise the project's appicon.png
+func readPNGIcon(options *Options, platform string) ([]byte, error) {
+	iconFile := platformIconFile(options, platform)
+	if strings.EqualFold(filepath.Ext(iconFile), ".png") {
+		return os.ReadFile(iconFile)
+	}
+	return buildassets.ReadFile(options.ProjectData, "appicon.png")
+}
+
+// loadWindowsIcon loads the icon embedded in Windows binaries: the Windows icon file, converted
+// to an .ico if it's a PNG, otherwise the given default .ico
+func loadWindowsIcon(options *Options, defaultIcoFile string) (*winres.Icon, error) {
+	iconFile := platformIconFile(options, "windows")
+	if iconFile == "" {
+		iconFile = defaultIcoFile
+	}
+
+	data, err := os.ReadFile(iconFile)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(iconFile), ".png") {
+		var ico bytes.Buffer
+		if err := winicon.GenerateIcon(bytes.NewReader(data), &ico, []int{256, 128, 64, 48, 32, 16}); err != nil {
+			return nil, fmt.Errorf("couldn't convert '%s' to an icon: %w", iconFile, err)
+		}
+		data = ico.Bytes()
+	}
+
+	icon, err := winres.LoadICO(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load icon from %s: %w", filepath.Base(iconFile), err)
+	}
+	return icon, nil
+}
+
+// validateIconFile checks the icon file of the given platform exists and its content matches its extension
+func validateIconFile(iconFile string, platform string) error {
+	extension := strings.ToLower(filepath.Ext(iconFile))
+	if !lo.Contains(iconFormats[platform], extension) {
+		return fmt.Errorf("invalid %s icon '%s': must be one of %s", platform, iconFile, strings.Join(iconFormats[platform], ", "))
+	}
+	data, err := os.ReadFile(iconFile)
+	if err != nil {
+		return fmt.Errorf("unable to read %s icon '%s': %w", platform, iconFile, err)
+	}
+
+	valid := false
+	switch extension {
+	case ".png":
+		_, err := png.DecodeConfig(bytes.NewReader(data))
+		valid = err == nil
+	case ".ico":
+		valid = bytes.HasPrefix(data, []byte{0, 0, 1, 0})
+	case ".icns":
+		valid = bytes.HasPrefix(data, []byte("icns"))
+	}
+	if !valid {
+		return fmt.Errorf("invalid %s icon '%s': not a valid %s file", platform, iconFile, strings.TrimPrefix(extension, "."))
+	}
+	return nil
+}
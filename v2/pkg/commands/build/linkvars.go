@@ -0,0 +1,44 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// linkVarsLDFlags returns the `-X` linker flags that set the LinkVars, in sorted order.
+// They are added after LDFlags, so they take precedence over -X flags for the same variables.
+func linkVarsLDFlags(options *Options) []string {
+	names := make([]string, 0, len(options.LinkVars))
+	for name := range options.LinkVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		// Invalid variables are reported by validateOptions
+		if flag, err := linkVarFlag(name, options.LinkVars[name]); err == nil {
+			result = append(result, flag)
+		}
+	}
+	return result
+}
+
+// linkVarFlag returns the `-X` linker flag that sets the given variable, EG: `-X 'main.title=My App'`.
+// The go command splits -ldflags on spaces unless a flag is quoted, and has no escapes inside the
+// quotes, so a value with spaces is quoted with whichever of ' or " it doesn't contain.
+func linkVarFlag(name string, value string) (string, error) {
+	if name == "" || strings.ContainsAny(name, " \t\n\r'\"=") || !strings.Contains(name, ".") {
+		return "", fmt.Errorf("invalid link variable '%s': must be a fully qualified variable, EG: main.version", name)
+	}
+	setting := name + "=" + value
+	if !strings.ContainsAny(value, " \t\n\r") {
+		return "-X " + setting, nil
+	}
+	for _, quote := range []string{"'", `"`} {
+		if !strings.Contains(value, quote) {
+			return "-X " + quote + setting + quote, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value for link variable '%s': values with spaces cannot contain both ' and \"", name)
+}
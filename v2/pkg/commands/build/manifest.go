@@ -0,0 +1,91 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// BuildManifest describes the output of a successful build
+type BuildManifest struct {
+	CompiledBinary string   `json:"compiledBinary"`
+	Bundle         string   `json:"bundle,omitempty"`
+	Platform       string   `json:"platform"`
+	Arch           string   `json:"arch"`
+	Mode           string   `json:"mode"`
+	LDFlags        string   `json:"ldflags"`
+	UserTags       []string `json:"userTags"`
+	Compressed     bool     `json:"compressed"`
+	Obfuscated     bool     `json:"obfuscated"`
+	SHA256         string   `json:"sha256"`
+}
+
+// buildResultPrefix starts the line EmitResultJSON writes to stdout, so tools can find it among the build output
+const buildResultPrefix = "WAILS_BUILD_RESULT="
+
+// buildResult is the JSON written after buildResultPrefix. Its fields are a stable contract for tools
+type buildResult struct {
+	Binary   string `json:"binary"`
+	Bundle   string `json:"bundle"`
+	Platform string `json:"platform"`
+	Arch     string `json:"arch"`
+}
+
+// writeBuildResult writes the WAILS_BUILD_RESULT line for the given compiled binary to w
+func writeBuildResult(w io.Writer, options *Options, binary string) error {
+	data, err := json.Marshal(buildResult{
+		Binary:   binary,
+		Bundle:   options.CompiledBundle,
+		Platform: options.Platform,
+		Arch:     options.Arch,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", buildResultPrefix, data)
+	return err
+}
+
+// writeBuildManifest writes the build manifest for the given options to options.ManifestFile
+func writeBuildManifest(options *Options) error {
+	checksum, err := sha256File(options.CompiledBinary)
+	if err != nil {
+		return err
+	}
+
+	manifest := BuildManifest{
+		CompiledBinary: options.CompiledBinary,
+		Bundle:         options.CompiledBundle,
+		Platform:       options.Platform,
+		Arch:           options.Arch,
+		Mode:           options.Mode.String(),
+		LDFlags:        resolveLDFlags(options),
+		UserTags:       options.UserTags,
+		Compressed:     options.CompressMethod != "" && options.CompressMethod != CompressNone,
+		Obfuscated:     options.Obfuscated,
+		SHA256:         checksum,
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(options.ManifestFile, data, 0644)
+}
+
+// sha256File returns the hex encoded SHA256 checksum of the given file
+func sha256File(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
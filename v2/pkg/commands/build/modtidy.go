@@ -0,0 +1,78 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// Supported values for Options.ModTidyMode
+const (
+	ModTidyRun    = "run"
+	ModTidySkip   = "skip"
+	ModTidyVerify = "verify"
+)
+
+// supportedModTidyModes lists the values accepted by Options.ModTidyMode. Empty is the same as run
+var supportedModTidyModes = []string{"", ModTidyRun, ModTidySkip, ModTidyVerify}
+
+// modTidyMode returns how `go mod tidy` is used before compiling. SkipModTidy, Offline and UseVendor take precedence over ModTidyMode.
+func (o *Options) modTidyMode() string {
+	if o.SkipModTidy || o.Offline || o.UseVendor {
+		return ModTidySkip
+	}
+	if o.ModTidyMode == "" {
+		return ModTidyRun
+	}
+	return o.ModTidyMode
+}
+
+// verifyModTidy runs `go mod tidy` on a temporary copy of the project's go.mod and go.sum
+// and returns an error if tidying would change them. The project's files are not modified.
+func verifyModTidy(options *Options) error {
+	projectDir := options.ProjectData.Path
+	tempDir, err := os.MkdirTemp("", "wails-modtidy")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+
+	files := []string{"go.mod", "go.sum"}
+	for _, filename := range files {
+		if !fs.FileExists(filepath.Join(projectDir, filename)) {
+			continue
+		}
+		if err := fs.CopyFile(filepath.Join(projectDir, filename), filepath.Join(tempDir, filename)); err != nil {
+			return err
+		}
+	}
+
+	// The go.sum used with -modfile is the one next to the given go.mod
+	cmd := exec.CommandContext(options.buildContext(), options.Compiler, "mod", "tidy", "-modfile="+filepath.Join(tempDir, "go.mod"))
+	cmd.Dir = projectDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unable to verify the module is tidy: %w - %s", err, stderr.String())
+	}
+
+	var changed []string
+	for _, filename := range files {
+		original, _ := os.ReadFile(filepath.Join(projectDir, filename))
+		tidied, _ := os.ReadFile(filepath.Join(tempDir, filename))
+		if !bytes.Equal(original, tidied) {
+			changed = append(changed, filename)
+		}
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("the module is not tidy: `go mod tidy` would change %s", strings.Join(changed, " and "))
+	}
+	return nil
+}
@@ -88,7 +88,7 @@ func GenerateNSISInstaller(options *Options, amd64Binary string, arm64Binary str
 }
 
 func makeNSIS(options *Options, installerKind string, amd64Binary string, arm64Binary string) error {
-	verbose := options.Verbosity == VERBOSE
+	verbose := options.verbosity() == VERBOSE
 	outputLogger := options.Logger
 
 	outputLogger.Print("  - Building '%s' installer: ", installerKind)
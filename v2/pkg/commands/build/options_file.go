@@ -0,0 +1,66 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// LoadOptionsFromFile loads build options from a JSON file, EG: wails.build.json.
+// Keys are the Options field names, matched case-insensitively. Mode is given as
+// dev, production or debug and durations as strings such as "90s".
+// The Logger and ProjectData are not loaded and fields not present keep their zero values.
+func LoadOptionsFromFile(path string) (*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var options Options
+	if err := json.Unmarshal(data, &options); err != nil {
+		return nil, fmt.Errorf("unable to load build options from %s: %w", path, err)
+	}
+	return &options, nil
+}
+
+// jsonOptions is used to (un)marshal Options with durations as strings
+type jsonOptions Options
+
+// MarshalJSON implements json.Marshaler
+func (o Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		jsonOptions
+		HookTimeout string `json:",omitempty"`
+	}{
+		jsonOptions: jsonOptions(o),
+		HookTimeout: formatDuration(o.HookTimeout),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *Options) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*jsonOptions
+		HookTimeout string
+	}{
+		jsonOptions: (*jsonOptions)(o),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.HookTimeout != "" {
+		hookTimeout, err := time.ParseDuration(aux.HookTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid HookTimeout: %w", err)
+		}
+		o.HookTimeout = hookTimeout
+	}
+	return nil
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration == 0 {
+		return ""
+	}
+	return duration.String()
+}
@@ -0,0 +1,56 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/project"
+)
+
+// outputNamePlaceholder matches the placeholders of an OutputNameTemplate, EG: {version}
+var outputNamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// outputNamePlaceholders lists the placeholders an OutputNameTemplate may use
+var outputNamePlaceholders = []string{"{name}", "{version}", "{platform}", "{arch}", "{mode}"}
+
+// expandOutputNameTemplate returns the OutputNameTemplate with its placeholders replaced.
+// EG: {name}-{version}-{platform}-{arch} gives myapp-1.2.3-linux-amd64
+func expandOutputNameTemplate(options *Options, projectData *project.Project) string {
+	replacer := strings.NewReplacer(
+		"{name}", strings.TrimSuffix(projectData.OutputFilename, ".exe"),
+		"{version}", projectData.Info.ProductVersion,
+		"{platform}", options.Platform,
+		"{arch}", options.Arch,
+		"{mode}", options.Mode.String(),
+	)
+	return replacer.Replace(options.OutputNameTemplate)
+}
+
+// validateOutputNameTemplate returns the problems with the OutputNameTemplate option
+func validateOutputNameTemplate(options *Options) []string {
+	template := options.OutputNameTemplate
+	if template == "" {
+		return nil
+	}
+	var problems []string
+	for _, placeholder := range outputNamePlaceholder.FindAllString(template, -1) {
+		if !lo.Contains(outputNamePlaceholders, placeholder) {
+			problems = append(problems, fmt.Sprintf("unknown placeholder %s in the output name template: must be one of %s", placeholder, strings.Join(outputNamePlaceholders, ", ")))
+		}
+	}
+	if strings.ContainsAny(template, `/\`) {
+		problems = append(problems, "the output name template must not contain path separators: use the bin directory to choose where binaries go")
+	}
+	if strings.ContainsAny(outputNamePlaceholder.ReplaceAllString(template, ""), `<>:"|?*`) {
+		problems = append(problems, "the output name template must not contain any of the characters <>:\"|?*")
+	}
+	if strings.Trim(template, ". ") == "" {
+		problems = append(problems, fmt.Sprintf("the output name template '%s' does not give a valid filename", template))
+	}
+	if len(compiledArchs(options)) > 1 && !strings.Contains(template, "{arch}") && options.Arch != "universal" {
+		problems = append(problems, "the output name template must contain {arch} when building several archs")
+	}
+	return problems
+}
@@ -0,0 +1,66 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// supportedPackageManagers lists the values accepted by Options.FrontendPackageManager
+var supportedPackageManagers = []string{"npm", "pnpm", "bun", "yarn"}
+
+// packageManagerLockfiles maps lockfiles to the package manager that creates them, in detection order
+var packageManagerLockfiles = []struct {
+	lockfile       string
+	packageManager string
+}{
+	{lockfile: "pnpm-lock.yaml", packageManager: "pnpm"},
+	{lockfile: "bun.lockb", packageManager: "bun"},
+	{lockfile: "bun.lock", packageManager: "bun"},
+}
+
+// frontendPackageManager returns the package manager used to install and build the frontend:
+//  1. Options.FrontendPackageManager, if set
+//  2. pnpm if the frontend directory has a pnpm-lock.yaml
+//  3. bun if the frontend directory has a bun.lockb or bun.lock
+//  4. npm
+//
+// An error is returned if it isn't installed.
+func frontendPackageManager(options *Options, frontendDir string) (string, error) {
+	packageManager := options.FrontendPackageManager
+	if packageManager == "" {
+		packageManager = "npm"
+		for _, candidate := range packageManagerLockfiles {
+			if fs.FileExists(filepath.Join(frontendDir, candidate.lockfile)) {
+				packageManager = candidate.packageManager
+				break
+			}
+		}
+	}
+	if !lo.Contains(supportedPackageManagers, packageManager) {
+		return "", fmt.Errorf("frontend package manager '%s' is not supported. Supported package managers: %s", packageManager, strings.Join(supportedPackageManagers, ", "))
+	}
+	if packageManager != "npm" && !shell.CommandExists(packageManager) {
+		return "", fmt.Errorf("the frontend package manager '%s' was not found on PATH. Please install it or set the frontend package manager to npm", packageManager)
+	}
+	return packageManager, nil
+}
+
+// packageManagerCommand returns the given npm command for the given package manager.
+// EG: `npm run build` becomes `pnpm run build`. Commands that don't use npm are returned as is.
+func packageManagerCommand(command string, packageManager string) string {
+	args := strings.Split(command, " ")
+	if args[0] != "npm" || packageManager == "npm" {
+		return command
+	}
+	args[0] = packageManager
+	// `npm ci` installs exactly what is in the lockfile
+	if len(args) > 1 && args[1] == "ci" {
+		args = append([]string{packageManager, "install", "--frozen-lockfile"}, args[2:]...)
+	}
+	return strings.Join(args, " ")
+}
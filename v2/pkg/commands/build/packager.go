@@ -2,19 +2,25 @@ package build
 
 import (
 	"bytes"
+	"encoding/xml"
 	"fmt"
 	"github.com/leaanthony/winicon"
 	"github.com/tc-hib/winres"
 	"github.com/tc-hib/winres/version"
 	"image"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/jackmordaunt/icns"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	"github.com/wailsapp/wails/v2/pkg/buildassets"
 
 	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/shell"
 )
 
 // PackageProject packages the application
@@ -28,6 +34,8 @@ func packageProject(options *Options, platform string) error {
 		err = packageApplicationForWindows(options)
 	case "linux":
 		err = packageApplicationForLinux(options)
+	case "freebsd":
+		err = packageApplicationForFreeBSD(options)
 	default:
 		err = fmt.Errorf("packing not supported for %s yet", platform)
 	}
@@ -36,6 +44,10 @@ func packageProject(options *Options, platform string) error {
 		return err
 	}
 
+	if options.CompiledBundle != "" {
+		options.addArtifact(options.CompiledBundle)
+	}
+
 	return nil
 }
 
@@ -46,7 +58,7 @@ func cleanBinDirectory(options *Options) error {
 
 	// Clear out old builds
 	if fs.DirExists(buildDirectory) {
-		err := os.RemoveAll(buildDirectory)
+		err := removeBinDirectory(options)
 		if err != nil {
 			return err
 		}
@@ -66,10 +78,7 @@ func packageApplicationForDarwin(options *Options) error {
 	var err error
 
 	// Create directory structure
-	bundlename := options.BundleName
-	if bundlename == "" {
-		bundlename = options.ProjectData.Name + ".app"
-	}
+	bundlename := bundleDirectoryName(options)
 
 	contentsDirectory := filepath.Join(options.BinDirectory, bundlename, "/Contents")
 	exeDir := filepath.Join(contentsDirectory, "/MacOS")
@@ -102,6 +111,7 @@ func packageApplicationForDarwin(options *Options) error {
 	}
 
 	options.CompiledBinary = packedBinaryPath
+	options.CompiledBundle = filepath.Join(options.BinDirectory, bundlename)
 
 	return nil
 }
@@ -119,13 +129,79 @@ func processPList(options *Options, contentsDirectory string) error {
 	if err != nil {
 		return err
 	}
+	if options.MacMinVersion != "" {
+		content = setPListString(content, "LSMinimumSystemVersion", options.MacMinVersion)
+	}
+	if name := bundleDisplayName(options); name != "" {
+		content = setPListString(content, "CFBundleName", name)
+		content = setPListString(content, "CFBundleDisplayName", name)
+	}
+	content, err = mergePListExtras(content, options.MacPlistExtras)
+	if err != nil {
+		return err
+	}
 
 	targetFile := filepath.Join(contentsDirectory, "Info.plist")
 	return os.WriteFile(targetFile, content, 0644)
 }
 
+// defaultMacMinVersion is the oldest macOS version Wails applications support
+const defaultMacMinVersion = "10.13"
+
+// macMinVersion returns the minimum macOS version to build for
+func macMinVersion(options *Options) string {
+	version, _ := lo.Coalesce(options.MacMinVersion, defaultMacMinVersion)
+	return version
+}
+
+// bundleDisplayName returns the name of the .app bundle given by BundleName, without the .app suffix.
+// Empty if no BundleName was given, in which case the name in the Info.plist is used.
+func bundleDisplayName(options *Options) string {
+	return strings.TrimSuffix(options.BundleName, ".app")
+}
+
+// bundleDirectoryName returns the name of the .app bundle directory: the BundleName or,
+// by default, the project name. Multi-arch builds and BundleArchSuffix add the arch so each
+// has its own bundle.
+func bundleDirectoryName(options *Options) string {
+	name, _ := lo.Coalesce(bundleDisplayName(options), options.ProjectData.Name)
+	suffix := options.bundleSuffix
+	if suffix == "" && options.BundleArchSuffix {
+		suffix = "-" + options.Arch
+	}
+	return name + suffix + ".app"
+}
+
+// setPListString sets the string value of the given key in the given Info.plist, adding it to the end of the
+// top level dict if it isn't there
+func setPListString(content []byte, key string, value string) []byte {
+	var escaped bytes.Buffer
+	_ = xml.EscapeText(&escaped, []byte(value))
+
+	existing := regexp.MustCompile(`(<key>` + regexp.QuoteMeta(key) + `</key>\s*<string>)[^<]*(</string>)`)
+	if match := existing.FindSubmatchIndex(content); match != nil {
+		// Replace the value between the <string> tags
+		result := append([]byte{}, content[:match[3]]...)
+		result = append(result, escaped.Bytes()...)
+		return append(result, content[match[4]:]...)
+	}
+	index := bytes.LastIndex(content, []byte("</dict>"))
+	if index == -1 {
+		return content
+	}
+	entry := []byte("    <key>" + key + "</key>\n        <string>" + escaped.String() + "</string>\n    ")
+	return append(content[:index:index], append(entry, content[index:]...)...)
+}
+
 func processApplicationIcon(options *Options, resourceDir string) (err error) {
-	appIcon, err := buildassets.ReadFile(options.ProjectData, "appicon.png")
+	tgtBundle := filepath.Join(resourceDir, "iconfile.icns")
+
+	// Use a prebuilt icns as is
+	if iconFile := platformIconFile(options, "darwin"); strings.EqualFold(filepath.Ext(iconFile), ".icns") {
+		return fs.CopyFile(iconFile, tgtBundle)
+	}
+
+	appIcon, err := readPNGIcon(options, "darwin")
 	if err != nil {
 		return err
 	}
@@ -135,7 +211,6 @@ func processApplicationIcon(options *Options, resourceDir string) (err error) {
 		return err
 	}
 
-	tgtBundle := filepath.Join(resourceDir, "iconfile.icns")
 	dest, err := os.Create(tgtBundle)
 	if err != nil {
 		return err
@@ -167,11 +242,109 @@ func packageApplicationForWindows(options *Options) error {
 	return nil
 }
 
-func packageApplicationForLinux(_ *Options) error {
+func packageApplicationForLinux(options *Options) error {
+	// Dev builds run the compiled binary directly
+	if options.OutputType == "dev" {
+		return nil
+	}
+
+	// Linux packages need extra tools, EG: appimagetool, so they are only created when a format is asked for
+	switch options.LinuxPackageFormat {
+	case "":
+		return nil
+	case LinuxPackageAppImage:
+		return packageAppImage(options)
+	case LinuxPackageDeb:
+		return packageDeb(options)
+	default:
+		return fmt.Errorf("linux package format '%s' not supported", options.LinuxPackageFormat)
+	}
+}
+
+// appImageArchs maps Go architectures to the names used by appimagetool
+var appImageArchs = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+	"arm":   "armhf",
+	"386":   "i686",
+}
+
+// packageAppImage creates an AppImage of the compiled binary next to it in the bin directory
+func packageAppImage(options *Options) error {
+	if !shell.CommandExists("appimagetool") {
+		return fmt.Errorf("cannot create AppImage: appimagetool not found on PATH. Please install it from https://github.com/AppImage/AppImageKit or build with -noPackage")
+	}
+	appImageArch, supported := appImageArchs[options.Arch]
+	if !supported {
+		return fmt.Errorf("cannot create AppImage: arch '%s' not supported", options.Arch)
+	}
+
+	name := options.ProjectData.Name
+	appDir := filepath.Join(options.BinDirectory, name+".AppDir")
+	_ = os.RemoveAll(appDir)
+	if !options.KeepAssets {
+		defer func() {
+			_ = os.RemoveAll(appDir)
+		}()
+	} else {
+		options.addArtifact(appDir)
+	}
+
+	binDir := filepath.Join(appDir, "usr", "bin")
+	err := fs.MkDirs(binDir, 0755)
+	if err != nil {
+		return err
+	}
+	err = fs.CopyFile(options.CompiledBinary, filepath.Join(binDir, name))
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(filepath.Join(binDir, name), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.Symlink(filepath.Join("usr", "bin", name), filepath.Join(appDir, "AppRun"))
+	if err != nil {
+		return err
+	}
+
+	desktopFile, err := buildassets.ReadFileWithProjectData(options.ProjectData, "linux/app.desktop")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(appDir, name+".desktop"), desktopFile, 0644)
+	if err != nil {
+		return err
+	}
+
+	appIcon, err := readPNGIcon(options, "linux")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(appDir, name+".png"), appIcon, 0644)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(options.BinDirectory, fmt.Sprintf("%s-%s.AppImage", name, options.Arch))
+	cmd := shell.CreateCommand(options.BinDirectory, "appimagetool", appDir, target)
+	cmd.Env = append(os.Environ(), "ARCH="+appImageArch)
+	var stde bytes.Buffer
+	cmd.Stderr = &stde
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error creating AppImage: %w - %s", err, stde.String())
+	}
+
+	options.CompiledBundle = target
 	return nil
 }
 
+// generateIcoFile generates the project's windows/icon.ico from appicon.png if it doesn't exist.
+// Nothing is generated when a Windows icon file is given, as loadWindowsIcon converts it.
 func generateIcoFile(options *Options) error {
+	if platformIconFile(options, "windows") != "" {
+		return nil
+	}
 	content, err := buildassets.ReadFile(options.ProjectData, "appicon.png")
 	if err != nil {
 		return err
@@ -195,41 +368,56 @@ func generateIcoFile(options *Options) error {
 		if err != nil {
 			return err
 		}
+		options.addArtifact(icoFile)
 	}
 	return nil
 }
 
-func compileResources(options *Options) error {
+// readWindowsManifest returns the application manifest to embed: the WindowsManifestFile if given,
+// otherwise the project's windows/wails.exe.manifest
+func readWindowsManifest(options *Options) ([]byte, error) {
+	if options.WindowsManifestFile == "" {
+		return buildassets.ReadFileWithProjectData(options.ProjectData, "windows/wails.exe.manifest")
+	}
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return err
+	manifestFile := options.WindowsManifestFile
+	if !filepath.IsAbs(manifestFile) {
+		manifestFile = filepath.Join(options.ProjectData.Path, manifestFile)
 	}
-	defer func() {
-		os.Chdir(currentDir)
-	}()
-	windowsDir := filepath.Join(options.ProjectData.GetBuildDir(), "windows")
-	err = os.Chdir(windowsDir)
+	manifestData, err := os.ReadFile(manifestFile)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Check the manifest is well-formed XML
+	decoder := xml.NewDecoder(bytes.NewReader(manifestData))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid Windows manifest '%s': %w", manifestFile, err)
+		}
 	}
+	return manifestData, nil
+}
+
+func compileResources(options *Options) error {
+
+	// Every path is absolute, so the process working directory is left alone for concurrent builds
+	windowsDir := filepath.Join(options.ProjectData.GetBuildDir(), "windows")
 	rs := winres.ResourceSet{}
-	icon := filepath.Join(windowsDir, "icon.ico")
-	iconFile, err := os.Open(icon)
+	ico, err := loadWindowsIcon(options, filepath.Join(windowsDir, "icon.ico"))
 	if err != nil {
 		return err
 	}
-	defer iconFile.Close()
-	ico, err := winres.LoadICO(iconFile)
-	if err != nil {
-		return fmt.Errorf("couldn't load icon from icon.ico: %w", err)
-	}
 	err = rs.SetIcon(winres.RT_ICON, ico)
 	if err != nil {
 		return err
 	}
 
-	manifestData, err := buildassets.ReadFileWithProjectData(options.ProjectData, "windows/wails.exe.manifest")
+	manifestData, err := readWindowsManifest(options)
 	if err != nil {
 		return err
 	}
@@ -259,6 +447,9 @@ func compileResources(options *Options) error {
 		return err
 	}
 	defer fout.Close()
+	if options.KeepAssets {
+		options.addArtifact(targetFile)
+	}
 
 	archs := map[string]winres.Arch{
 		"amd64": winres.ArchAMD64,
@@ -0,0 +1,62 @@
+package build
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// PGOAuto uses the default.pgo profile in the main package, as `go build -pgo=auto` does
+const PGOAuto = "auto"
+
+// defaultPGOProfile is the profile go uses when building with -pgo=auto
+const defaultPGOProfile = "default.pgo"
+
+// minimumPGOGoVersion is the first Go release that supports profile-guided optimization
+var minimumPGOGoVersion = semver.MustParse("1.21")
+
+// mainPackageDir returns the directory of the main package that is built
+func mainPackageDir(options *Options) string {
+	if options.EntryPoint == "" {
+		return options.ProjectData.Path
+	}
+	if filepath.IsAbs(options.EntryPoint) {
+		return options.EntryPoint
+	}
+	return filepath.Join(options.ProjectData.Path, options.EntryPoint)
+}
+
+// pgoProfile returns the profile file to build with for the PGOProfile option, relative
+// paths being relative to the project. Auto uses the main package's default.pgo.
+func pgoProfile(options *Options) string {
+	switch {
+	case options.PGOProfile == PGOAuto:
+		return filepath.Join(mainPackageDir(options), defaultPGOProfile)
+	case filepath.IsAbs(options.PGOProfile):
+		return options.PGOProfile
+	default:
+		return filepath.Join(options.ProjectData.Path, options.PGOProfile)
+	}
+}
+
+// checkPGO warns if profile-guided optimization was requested with a go toolchain older than
+// Go 1.21, which doesn't support it, or with no default.pgo to use
+func checkPGO(options *Options) {
+	if options.PGOProfile == PGOAuto && !fs.FileExists(pgoProfile(options)) {
+		options.Logger.Println("Warning: no %s found in '%s'. The application will be built without profile-guided optimization", defaultPGOProfile, mainPackageDir(options))
+	}
+
+	stdout, err := goVersion(options)
+	if err != nil {
+		return
+	}
+	match := goVersionRegex.FindStringSubmatch(stdout)
+	if match == nil {
+		return
+	}
+	if version, err := semver.NewVersion(match[1]); err == nil && version.LessThan(minimumPGOGoVersion) {
+		options.Logger.Println("Warning: profile-guided optimization requires Go %s or later but the compiler is %s", minimumPGOGoVersion, strings.TrimSpace(stdout))
+	}
+}
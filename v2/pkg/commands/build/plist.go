@@ -0,0 +1,189 @@
+package build
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// PlistExtras are the keys added to the generated Info.plist, EG: NSCameraUsageDescription.
+// Values may be strings, booleans, numbers, slices and maps of these, which are written as
+// the matching plist types. Keys already in the Info.plist are replaced.
+type PlistExtras map[string]interface{}
+
+// plistEntry is the position of a key and its value in the top level dict of an Info.plist
+type plistEntry struct {
+	key        string
+	start, end int64
+}
+
+// parsePList returns the entries of the top level dict of the given Info.plist and the
+// position of its closing tag. An error is returned if the plist is not well-formed.
+func parsePList(content []byte) ([]plistEntry, int64, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var entries []plistEntry
+	dictEnd := int64(-1)
+	depth := 0
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid Info.plist: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			// depth 1 is the <plist>, 2 the top level <dict> and 3 its keys and values
+			if depth != 3 || token.Name.Local != "key" {
+				continue
+			}
+			var key string
+			if err := decoder.DecodeElement(&key, &token); err != nil {
+				return nil, 0, fmt.Errorf("invalid Info.plist: %w", err)
+			}
+			depth--
+			entries = append(entries, plistEntry{key: key, start: offset})
+		case xml.EndElement:
+			if depth == 3 && len(entries) > 0 && entries[len(entries)-1].end == 0 {
+				entries[len(entries)-1].end = decoder.InputOffset()
+			}
+			if depth == 2 && token.Name.Local == "dict" {
+				dictEnd = offset
+			}
+			depth--
+		}
+	}
+	if dictEnd == -1 {
+		return nil, 0, fmt.Errorf("invalid Info.plist: no top level dict")
+	}
+	return entries, dictEnd, nil
+}
+
+// mergePListExtras adds the given extras to the top level dict of the given Info.plist,
+// replacing the existing values of the same keys. The result is checked to parse.
+func mergePListExtras(content []byte, extras PlistExtras) ([]byte, error) {
+	if len(extras) == 0 {
+		return content, nil
+	}
+	entries, dictEnd, err := parsePList(content)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(extras))
+	for key := range extras {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var added strings.Builder
+	for _, key := range keys {
+		value, err := encodePListValue(extras[key], "        ")
+		if err != nil {
+			return nil, fmt.Errorf("invalid Info.plist value for '%s': %w", key, err)
+		}
+		added.WriteString("    <key>" + escapePListText(key) + "</key>\n        " + value + "\n    ")
+	}
+
+	var result bytes.Buffer
+	previous := int64(0)
+	for _, entry := range entries {
+		if _, replaced := extras[entry.key]; !replaced {
+			continue
+		}
+		// Remove the indentation of the entry as well
+		start := int64(bytes.LastIndexByte(content[:entry.start], '\n') + 1)
+		if len(bytes.TrimSpace(content[start:entry.start])) != 0 {
+			start = entry.start
+		}
+		result.Write(content[previous:start])
+		previous = entry.end
+		if next := bytes.IndexByte(content[previous:], '\n'); next != -1 && len(bytes.TrimSpace(content[previous:previous+int64(next)])) == 0 {
+			previous += int64(next) + 1
+		}
+	}
+	result.Write(content[previous:dictEnd])
+	result.WriteString(added.String())
+	result.Write(content[dictEnd:])
+
+	if _, _, err := parsePList(result.Bytes()); err != nil {
+		return nil, fmt.Errorf("the Info.plist is invalid after adding the extra keys: %w", err)
+	}
+	return result.Bytes(), nil
+}
+
+// encodePListValue returns the given value as a plist element. Nested elements are indented from the given indent
+func encodePListValue(value interface{}, indent string) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return "<string>" + escapePListText(value) + "</string>", nil
+	case bool:
+		if value {
+			return "<true/>", nil
+		}
+		return "<false/>", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("<integer>%d</integer>", value), nil
+	case float32:
+		return encodePListValue(float64(value), indent)
+	case float64:
+		// Numbers loaded from JSON are float64
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return fmt.Sprintf("<integer>%d</integer>", int64(value)), nil
+		}
+		return fmt.Sprintf("<real>%v</real>", value), nil
+	case []string:
+		values := make([]interface{}, len(value))
+		for i, v := range value {
+			values[i] = v
+		}
+		return encodePListValue(values, indent)
+	case []interface{}:
+		var result strings.Builder
+		result.WriteString("<array>\n")
+		for _, v := range value {
+			encoded, err := encodePListValue(v, indent+"    ")
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(indent + "    " + encoded + "\n")
+		}
+		result.WriteString(indent + "</array>")
+		return result.String(), nil
+	case PlistExtras:
+		return encodePListValue(map[string]interface{}(value), indent)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var result strings.Builder
+		result.WriteString("<dict>\n")
+		for _, key := range keys {
+			encoded, err := encodePListValue(value[key], indent+"    ")
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(indent + "    <key>" + escapePListText(key) + "</key>\n")
+			result.WriteString(indent + "    " + encoded + "\n")
+		}
+		result.WriteString(indent + "</dict>")
+		return result.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// escapePListText escapes the given text for use in a plist element
+func escapePListText(text string) string {
+	var escaped bytes.Buffer
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,83 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/staticanalysis"
+)
+
+// prebuiltFrontendDir returns the PrebuiltFrontendDir, relative paths being relative to the project
+func prebuiltFrontendDir(options *Options) string {
+	if filepath.IsAbs(options.PrebuiltFrontendDir) {
+		return options.PrebuiltFrontendDir
+	}
+	return filepath.Join(options.ProjectData.Path, options.PrebuiltFrontendDir)
+}
+
+// frontendEmbedDir returns the embedded directory the built frontend goes in: the only directory embedded
+// from the frontend directory or, if none is, the only directory embedded by the project, EG: frontend/dist
+func frontendEmbedDir(options *Options) (string, error) {
+	embedDetails, err := staticanalysis.GetEmbedDetails(options.ProjectData.Path)
+	if err != nil {
+		return "", err
+	}
+	return selectFrontendEmbedDir(embedDetails, options.ProjectData.GetFrontendDir())
+}
+
+// selectFrontendEmbedDir returns the directory of the given embeds the built frontend goes in. See frontendEmbedDir
+func selectFrontendEmbedDir(embedDetails []*staticanalysis.EmbedDetails, frontendDir string) (string, error) {
+	var embedDirs, frontendEmbedDirs []string
+	for _, embedDetail := range embedDetails {
+		if embedDetail.IsFile {
+			continue
+		}
+		embedDir := embedDetail.GetFullPath()
+		embedDirs = append(embedDirs, embedDir)
+		if isWithinDir(embedDir, frontendDir) {
+			frontendEmbedDirs = append(frontendEmbedDirs, embedDir)
+		}
+	}
+	switch {
+	case len(frontendEmbedDirs) == 1:
+		return frontendEmbedDirs[0], nil
+	case len(frontendEmbedDirs) == 0 && len(embedDirs) == 1:
+		return embedDirs[0], nil
+	case len(embedDirs) == 0:
+		return "", fmt.Errorf("the project embeds no directory to copy the prebuilt frontend to")
+	default:
+		return "", fmt.Errorf("cannot tell which embedded directory the prebuilt frontend goes in: %s", strings.Join(embedDirs, ", "))
+	}
+}
+
+// copyPrebuiltFrontend replaces the contents of the frontend's embedded directory with the PrebuiltFrontendDir
+func copyPrebuiltFrontend(options *Options) error {
+	target, err := frontendEmbedDir(options)
+	if err != nil {
+		return err
+	}
+	return replaceDirectory(target, prebuiltFrontendDir(options))
+}
+
+// replaceDirectory replaces the target directory with a copy of the source directory
+func replaceDirectory(target string, source string) error {
+	if filepath.Clean(source) == filepath.Clean(target) {
+		return nil
+	}
+	if isWithinDir(source, target) || isWithinDir(target, source) {
+		return fmt.Errorf("the prebuilt frontend '%s' and the embedded directory '%s' must not contain each other", source, target)
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	return fs.CopyDir(source, target)
+}
+
+// isWithinDir returns true if the given path is the given directory or inside it
+func isWithinDir(path string, dir string) bool {
+	relative, err := filepath.Rel(dir, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,94 @@
+package build
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// Supported values for Options.PrecompressAssets
+const (
+	PrecompressNone   = "none"
+	PrecompressGzip   = "gzip"
+	PrecompressBrotli = "brotli"
+)
+
+// supportedPrecompressions lists the values accepted by Options.PrecompressAssets. Empty is the same as none
+var supportedPrecompressions = []string{"", PrecompressNone, PrecompressGzip, PrecompressBrotli}
+
+// precompressMinSize is the size below which assets are not precompressed, as compression gains little
+const precompressMinSize = 1024
+
+// compressibleAssetExtensions lists the extensions of the assets worth precompressing. Images, fonts
+// such as woff2 and archives are already compressed so they are left alone
+var compressibleAssetExtensions = []string{
+	".html", ".htm", ".css", ".js", ".mjs", ".json", ".map", ".svg", ".xml", ".txt", ".wasm", ".ico", ".ttf", ".otf", ".eot",
+}
+
+// precompressAssets writes a .gz or .br sibling, depending on PrecompressAssets, of each compressible
+// asset in the given directory, so they are embedded and can be served with a Content-Encoding
+func precompressAssets(options *Options, distDir string) error {
+	if options.PrecompressAssets == PrecompressBrotli && !shell.CommandExists("brotli") {
+		return fmt.Errorf("the 'brotli' command was not found. Please install it or precompress the assets with gzip")
+	}
+	var assets []string
+	err := filepath.Walk(distDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		extension := strings.ToLower(filepath.Ext(path))
+		for _, compressible := range compressibleAssetExtensions {
+			if extension == compressible && info.Size() >= precompressMinSize {
+				assets = append(assets, path)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, asset := range assets {
+		if options.PrecompressAssets == PrecompressBrotli {
+			_, stderr, err := shell.RunCommandWithContext(options.buildContext(), filepath.Dir(asset), "brotli", "--force", "--keep", "--best", filepath.Base(asset))
+			if err != nil {
+				return fmt.Errorf("error compressing %s: %w - %s", asset, err, stderr)
+			}
+			continue
+		}
+		if err := gzipFile(asset, asset+".gz"); err != nil {
+			return fmt.Errorf("error compressing %s: %w", asset, err)
+		}
+	}
+	return nil
+}
+
+// gzipFile writes the given file gzipped at its best compression to the target
+func gzipFile(source string, target string) error {
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	output, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	gzipWriter, err := gzip.NewWriterLevel(output, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(gzipWriter, input); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return output.Close()
+}
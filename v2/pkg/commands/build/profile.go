@@ -0,0 +1,46 @@
+package build
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Supported values for Options.OptimizeFor
+const (
+	OptimizeForSize  = "size"
+	OptimizeForSpeed = "speed"
+)
+
+// supportedOptimizationProfiles lists the values accepted by Options.OptimizeFor
+var supportedOptimizationProfiles = []string{"", OptimizeForSize, OptimizeForSpeed}
+
+// applyOptimizationProfile sets the defaults of the selected OptimizeFor profile.
+// Options that have already been set are left untouched so they can override the profile.
+//
+// The size profile sets:
+//   - StripSymbols: true (-w -s linker flags)
+//   - TrimPath: true (-trimpath)
+//   - CompressMethod: upx, if no method was given. Not used for darwin universal builds
+//   - RaceDetector: false, as the race detector more than doubles the binary size
+//
+// The speed profile sets:
+//   - AMD64Level: v3 (GOAMD64=v3), if no level was given and amd64 is being built
+func applyOptimizationProfile(options *Options) {
+	switch options.OptimizeFor {
+	case OptimizeForSize:
+		options.StripSymbols = true
+		options.TrimPath = true
+		if options.CompressMethod == "" && !(options.Platform == "darwin" && options.Arch == "universal") {
+			options.CompressMethod = CompressUPX
+		}
+		if options.RaceDetector {
+			options.Logger.Println("Warning: the race detector is not used when optimizing for size. Ignoring.")
+			options.RaceDetector = false
+		}
+	case OptimizeForSpeed:
+		if options.AMD64Level == "" && lo.Contains(strings.Split(options.Arch, ","), "amd64") {
+			options.AMD64Level = "v3"
+		}
+	}
+}
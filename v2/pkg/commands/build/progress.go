@@ -0,0 +1,23 @@
+package build
+
+// PhaseComplete is the phase of the event sent when a build has finished
+const PhaseComplete = "complete"
+
+// BuildEvent describes a milestone reached during a build
+type BuildEvent struct {
+	Phase   string // One of the Phase constants
+	Message string // A human readable description of the milestone
+	Percent int    // Approximate completion of the build, 0-100
+}
+
+// reportProgress sends a BuildEvent to the ProgressFunc, if one has been given
+func (o *Options) reportProgress(phase string, message string, percent int) {
+	if o.ProgressFunc == nil {
+		return
+	}
+	o.ProgressFunc(BuildEvent{
+		Phase:   phase,
+		Message: message,
+		Percent: percent,
+	})
+}
@@ -0,0 +1,60 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// Reproducible builds produce byte-identical output from the same commit on any machine.
+// The measures applied when Options.Reproducible is set are:
+//   - TrimPath: file system paths are removed from the Go binary (-trimpath)
+//   - the `-buildid=` linker flag, so the binary has no build ID
+//   - CGO_CFLAGS and CGO_CXXFLAGS get -ffile-prefix-map, so the project path isn't in the C debug info
+//   - GOFLAGS is replaced rather than inherited, so flags in the environment can't change the build.
+//     Only the -mod flag needed by Offline or UseVendor is kept
+//   - the build timestamp is SOURCE_DATE_EPOCH, or the time of the last git commit if that isn't set.
+//     It is used for the InjectBuildInfo build time and the tarball file times, and SOURCE_DATE_EPOCH
+//     is passed to dpkg-deb, which also gets --root-owner-group so the .deb has no local user
+//
+// The Windows .syso and the darwin Info.plist contain no timestamps so need no changes.
+// Signing and notarizing add timestamps and signatures, so signed bundles are not reproducible.
+
+// resolveSourceDate returns the timestamp of a reproducible build: SOURCE_DATE_EPOCH if it is
+// set, otherwise the time of the project's last git commit
+func resolveSourceDate(options *Options) (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		stdout, _, err := shell.RunCommandWithContext(options.buildContext(), options.ProjectData.Path, "git", "log", "-1", "--format=%ct")
+		if err != nil || strings.TrimSpace(stdout) == "" {
+			return time.Time{}, fmt.Errorf("reproducible builds need a fixed timestamp. Please set SOURCE_DATE_EPOCH or build from a git repository")
+		}
+		epoch = strings.TrimSpace(stdout)
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH '%s': must be a Unix timestamp", epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// buildTimestamp returns the time to record in the build output: the source date of
+// reproducible builds, otherwise the current time
+func (o *Options) buildTimestamp() time.Time {
+	if o.Reproducible && !o.sourceDate.IsZero() {
+		return o.sourceDate
+	}
+	return time.Now()
+}
+
+// reproducibleCFlags returns the given CGO_CFLAGS or CGO_CXXFLAGS value with the project path remapped
+func reproducibleCFlags(flags string, projectDir string) string {
+	if flags != "" {
+		flags += " "
+	}
+	return flags + "-ffile-prefix-map=" + projectDir + "=."
+}
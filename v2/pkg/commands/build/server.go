@@ -0,0 +1,13 @@
+package build
+
+// ServerBuilder builds applications that serve their frontend over HTTP instead of a WebView.
+// A server has no window, so on Windows it is always built as a console application.
+type ServerBuilder struct {
+	*BaseBuilder
+}
+
+func newServerBuilder(options *Options) *ServerBuilder {
+	return &ServerBuilder{
+		BaseBuilder: NewBaseBuilder(options),
+	}
+}
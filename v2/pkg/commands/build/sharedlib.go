@@ -0,0 +1,41 @@
+package build
+
+import "strings"
+
+// BuildModeCShared builds the application as a C shared library and header
+const BuildModeCShared = "c-shared"
+
+// supportedBuildModes lists the values accepted by Options.BuildMode. Empty builds an executable
+var supportedBuildModes = []string{"", BuildModeCShared}
+
+// sharedLibraryExtensions maps platforms to the extension of their shared libraries
+var sharedLibraryExtensions = map[string]string{
+	"darwin":  ".dylib",
+	"windows": ".dll",
+}
+
+// isSharedLibrary indicates if the application is built as a shared library rather than an executable.
+// Shared libraries are embedded in other applications, so they are never packaged or lipo'd.
+func (o *Options) isSharedLibrary() bool {
+	return o.BuildMode == BuildModeCShared
+}
+
+// sharedLibraryExtension returns the extension of shared libraries on the given platform. EG: .so
+func sharedLibraryExtension(platform string) string {
+	if extension, ok := sharedLibraryExtensions[platform]; ok {
+		return extension
+	}
+	return ".so"
+}
+
+// sharedLibraryFilename returns the given filename with the shared library extension of the given platform.
+// EG: app.exe becomes app.dll on windows and app-linux-amd64 becomes app-linux-amd64.so on linux
+func sharedLibraryFilename(filename string, platform string) string {
+	extension := sharedLibraryExtension(platform)
+	return strings.TrimSuffix(strings.TrimSuffix(filename, ".exe"), extension) + extension
+}
+
+// sharedLibraryHeader returns the C header the go toolchain generates next to the given shared library
+func sharedLibraryHeader(library string, platform string) string {
+	return strings.TrimSuffix(library, sharedLibraryExtension(platform)) + ".h"
+}
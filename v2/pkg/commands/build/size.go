@@ -0,0 +1,128 @@
+package build
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// binarySizesFile is the file in the project build directory that holds the binary sizes of the last build of each target
+const binarySizesFile = "binary-sizes.json"
+
+// binarySize is the recorded size of a compiled binary
+type binarySize struct {
+	Size     int64            `json:"size"`
+	Sections map[string]int64 `json:"sections,omitempty"`
+}
+
+// reportBinarySize prints the size of the compiled binary and how it changed since the last build
+// of the same platform and arch, then records it for the next build.
+// In verbose mode the sections that changed size are listed too.
+func reportBinarySize(options *Options) error {
+	info, err := os.Stat(options.CompiledBinary)
+	if err != nil {
+		return err
+	}
+	current := binarySize{
+		Size:     info.Size(),
+		Sections: sectionSizes(options.CompiledBinary),
+	}
+
+	sizesFile := filepath.Join(options.ProjectData.GetBuildDir(), binarySizesFile)
+	sizes := map[string]binarySize{}
+	if fs.FileExists(sizesFile) {
+		data, err := os.ReadFile(sizesFile)
+		if err != nil {
+			return err
+		}
+		// A corrupt record is replaced rather than failing the build
+		_ = json.Unmarshal(data, &sizes)
+	}
+
+	target := options.Platform + "/" + options.Arch
+	previous, found := sizes[target]
+	if !found {
+		options.Logger.Println("  - Binary size: %s", formatSize(current.Size))
+	} else {
+		options.Logger.Println("  - Binary size: %s (%s since last build)", formatSize(current.Size), formatSizeDelta(current.Size-previous.Size))
+		if options.verbosity() == VERBOSE {
+			names := make([]string, 0, len(current.Sections))
+			for name := range current.Sections {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if delta := current.Sections[name] - previous.Sections[name]; delta != 0 {
+					options.Logger.Println("      %s: %s (%s)", name, formatSize(current.Sections[name]), formatSizeDelta(delta))
+				}
+			}
+		}
+	}
+
+	sizes[target] = current
+	data, err := json.MarshalIndent(sizes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fs.MkDirs(filepath.Dir(sizesFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(sizesFile, data, 0644)
+}
+
+// sectionSizes returns the size of each section of the given ELF, Mach-O or PE binary.
+// Nil is returned for other files, such as darwin universal binaries.
+func sectionSizes(filename string) map[string]int64 {
+	result := map[string]int64{}
+	if file, err := elf.Open(filename); err == nil {
+		defer file.Close()
+		for _, section := range file.Sections {
+			if section.Name != "" {
+				result[section.Name] = int64(section.Size)
+			}
+		}
+		return result
+	}
+	if file, err := macho.Open(filename); err == nil {
+		defer file.Close()
+		for _, section := range file.Sections {
+			result[section.Seg+","+section.Name] = int64(section.Size)
+		}
+		return result
+	}
+	if file, err := pe.Open(filename); err == nil {
+		defer file.Close()
+		for _, section := range file.Sections {
+			result[section.Name] = int64(section.Size)
+		}
+		return result
+	}
+	return nil
+}
+
+// formatSize returns the given number of bytes in B, KB or MB. EG: 18.4 MB
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+	case bytes >= 1024:
+		return fmt.Sprintf("%d KB", bytes/1024)
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// formatSizeDelta returns the given change in size with its sign. EG: +320 KB
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + formatSize(-delta)
+	}
+	return "+" + formatSize(delta)
+}
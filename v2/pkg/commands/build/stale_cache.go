@@ -0,0 +1,47 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// staleCacheErrors are found in the output of compiles that failed because the go build cache is corrupted
+var staleCacheErrors = []string{
+	"compiled Go object is stale",
+	"stale dependency",
+	"cache entry",
+	"failed to trim cache",
+}
+
+// isStaleCacheError indicates if the given compile error was caused by a corrupted go build cache
+func isStaleCacheError(err error) bool {
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		return false
+	}
+	for _, message := range staleCacheErrors {
+		if strings.Contains(compileErr.Output, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileWithStaleCacheRetry runs the given compile. If it fails because the go build cache is corrupted
+// and AutoCleanCacheOnStale is set, the cache is cleaned with `go clean -cache` and the compile is retried once.
+func compileWithStaleCacheRetry(options *Options, compile func() error) error {
+	err := compile()
+	if err == nil || !options.AutoCleanCacheOnStale || options.DryRun || !isStaleCacheError(err) {
+		return err
+	}
+
+	options.Logger.Println("\nWarning: the compile failed because the go build cache is stale. Running `go clean -cache` and retrying.")
+	cmd := exec.CommandContext(options.buildContext(), options.Compiler, "clean", "-cache")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("unable to clean the go build cache: %w - %s", err, strings.TrimSpace(string(output)))
+	}
+	options.Logger.Print("  - Compiling application (retry): ")
+	return compile()
+}
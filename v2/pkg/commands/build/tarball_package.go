@@ -0,0 +1,85 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/pkg/buildassets"
+)
+
+func packageApplicationForFreeBSD(options *Options) error {
+	// Dev builds run the compiled binary directly
+	if options.OutputType == "dev" {
+		return nil
+	}
+	return packageTarball(options)
+}
+
+// packageTarball creates a .tar.gz of the compiled binary, desktop file and icon in the bin directory.
+// Everything is placed in a directory named after the project, EG: myapp/myapp, myapp/myapp.desktop.
+// File modes are relative to that directory, EG: myapp.desktop.
+func packageTarball(options *Options) error {
+	projectData := options.ProjectData
+	name := projectData.Name
+
+	desktopFile, err := buildassets.ReadFileWithProjectData(projectData, "linux/app.desktop")
+	if err != nil {
+		return err
+	}
+	appIcon, err := readPNGIcon(options, options.Platform)
+	if err != nil {
+		return err
+	}
+	binary, err := os.ReadFile(options.CompiledBinary)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(options.BinDirectory, fmt.Sprintf("%s-%s-%s.tar.gz", name, options.Platform, options.Arch))
+	output, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	gzipWriter := gzip.NewWriter(output)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	files := []struct {
+		name    string
+		mode    int64
+		content []byte
+	}{
+		{name: name, mode: int64(linuxFileMode(options, name, 0755) | executableMode), content: binary},
+		{name: name + ".desktop", mode: int64(linuxFileMode(options, name+".desktop", 0644)), content: desktopFile},
+		{name: name + ".png", mode: int64(linuxFileMode(options, name+".png", 0644)), content: appIcon},
+	}
+	modTime := options.buildTimestamp()
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    name + "/" + file.name,
+			Mode:    file.mode,
+			Size:    int64(len(file.content)),
+			ModTime: modTime,
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("error creating tarball: %w", err)
+		}
+		if _, err := tarWriter.Write(file.content); err != nil {
+			return fmt.Errorf("error creating tarball: %w", err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("error creating tarball: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("error creating tarball: %w", err)
+	}
+
+	options.CompiledBundle = target
+	return nil
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+// The phases of a build
+const (
+	PhaseSetup     = "setup"
+	PhaseBindings  = "bindings"
+	PhaseFrontend  = "frontend"
+	PhaseCompile   = "compile"
+	PhasePackaging = "packaging"
+	PhasePostBuild = "post-build"
+)
+
+// buildPhases lists the phases of a build in the order they run
+var buildPhases = []string{PhaseSetup, PhaseBindings, PhaseFrontend, PhaseCompile, PhasePackaging, PhasePostBuild}
+
+// BuildTimings holds the time taken by each phase of a build, keyed by the phase name
+type BuildTimings map[string]time.Duration
+
+// record adds the time since start to the given phase
+func (t BuildTimings) record(phase string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t[phase] += time.Since(start)
+}
+
+// printSummary writes a table of the phase timings to the logger
+func (t BuildTimings) printSummary(options *Options) {
+	w := tabwriter.NewWriter(options.Logger.Writer, 8, 8, 1, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  Build timings:")
+	for _, phase := range []string{PhaseBindings, PhaseFrontend, PhaseCompile, PhasePackaging} {
+		duration, ok := t[phase]
+		if !ok {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "    %s:\t%s\n", phase, duration.Round(time.Millisecond))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,316 @@
+package build
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/shell"
+)
+
+// raceDetectorTargets lists the platforms and architectures the race detector supports
+var raceDetectorTargets = []string{
+	"darwin/amd64",
+	"darwin/arm64",
+	"freebsd/amd64",
+	"linux/amd64",
+	"linux/arm64",
+	"windows/amd64",
+}
+
+// supportedPlatforms lists the platforms that Wails applications can be built and packaged for.
+// The archs of each are the ones the compiler supports, see goTargets.
+var supportedPlatforms = []string{"darwin", "freebsd", "linux", "windows"}
+
+// goTargets returns the GOOS/GOARCH pairs the compiler can build for, as listed by `go tool dist list`
+func goTargets(options *Options) ([]string, error) {
+	stdout, stderr, err := shell.RunCommandWithContext(options.buildContext(), "", options.Compiler, "tool", "dist", "list")
+	if err != nil {
+		return nil, fmt.Errorf("%s - %s", err.Error(), stderr)
+	}
+	return strings.Fields(stdout), nil
+}
+
+// platformArchs returns the archs of the given platform in the given GOOS/GOARCH pairs.
+// Universal binaries can be built for darwin as well.
+func platformArchs(targets []string, platform string) []string {
+	var archs []string
+	for _, target := range targets {
+		if goos, arch, _ := strings.Cut(target, "/"); goos == platform {
+			archs = append(archs, arch)
+		}
+	}
+	if platform == "darwin" {
+		archs = append(archs, "universal")
+	}
+	return archs
+}
+
+// macVersionRegex matches macOS versions, EG: 11 or 10.15.7
+var macVersionRegex = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// supportedOutputTypes lists the output types that can be built
+var supportedOutputTypes = []string{"desktop", "dev", "server"}
+
+// validateOptions checks the options before starting a build.
+// All the problems found are reported in a single error.
+func validateOptions(options *Options) error {
+	var problems []string
+
+	if options.ProjectData == nil {
+		return fmt.Errorf("invalid build options: no project data given")
+	}
+
+	projectData := options.ProjectData
+	if options.WorkingDir != "" && !fs.DirExists(options.WorkingDir) {
+		problems = append(problems, fmt.Sprintf("working directory '%s' does not exist", options.WorkingDir))
+	}
+	if projectData.Name == "" {
+		problems = append(problems, "the project has no name")
+	}
+	if !fs.DirExists(projectData.Path) {
+		problems = append(problems, fmt.Sprintf("project directory '%s' does not exist", projectData.Path))
+	}
+	if !options.IgnoreFrontend && !fs.DirExists(projectData.GetFrontendDir()) {
+		problems = append(problems, fmt.Sprintf("frontend directory '%s' does not exist", projectData.GetFrontendDir()))
+	}
+
+	if !lo.Contains(supportedOutputTypes, options.OutputType) {
+		problems = append(problems, fmt.Sprintf("output type '%s' is not supported. Supported types: %s", options.OutputType, strings.Join(supportedOutputTypes, ", ")))
+	}
+
+	if !lo.Contains(supportedOptimizationProfiles, options.OptimizeFor) {
+		problems = append(problems, fmt.Sprintf("optimization profile '%s' is not supported. Supported profiles: %s, %s", options.OptimizeFor, OptimizeForSize, OptimizeForSpeed))
+	}
+
+	if !lo.Contains(supportedBuildModes, options.BuildMode) {
+		problems = append(problems, fmt.Sprintf("build mode '%s' is not supported. Supported modes: %s", options.BuildMode, BuildModeCShared))
+	} else if options.BuildMode == BuildModeCShared && options.Arch == "universal" {
+		problems = append(problems, "shared libraries cannot be built as universal binaries")
+	}
+
+	if options.LinuxPackageFormat != "" && options.LinuxPackageFormat != LinuxPackageAppImage && options.LinuxPackageFormat != LinuxPackageDeb {
+		problems = append(problems, fmt.Sprintf("linux package format '%s' is not supported. Supported formats: %s, %s", options.LinuxPackageFormat, LinuxPackageAppImage, LinuxPackageDeb))
+	}
+
+	if options.FrontendPackageManager != "" && !lo.Contains(supportedPackageManagers, options.FrontendPackageManager) {
+		problems = append(problems, fmt.Sprintf("frontend package manager '%s' is not supported. Supported package managers: %s", options.FrontendPackageManager, strings.Join(supportedPackageManagers, ", ")))
+	}
+
+	if options.MinGoVersion != "" {
+		if _, err := semver.NewVersion(options.MinGoVersion); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid minimum Go version '%s': must be a Go release such as 1.21", options.MinGoVersion))
+		}
+	}
+
+	if !lo.Contains(supportedPrecompressions, options.PrecompressAssets) {
+		problems = append(problems, fmt.Sprintf("asset precompression '%s' is not supported. Supported values: %s, %s, %s", options.PrecompressAssets, PrecompressNone, PrecompressGzip, PrecompressBrotli))
+	}
+
+	if !lo.Contains(supportedModTidyModes, options.ModTidyMode) {
+		problems = append(problems, fmt.Sprintf("mod tidy mode '%s' is not supported. Supported modes: %s, %s, %s", options.ModTidyMode, ModTidyRun, ModTidySkip, ModTidyVerify))
+	}
+
+	// The compiler may be a command on the PATH or the path to a specific toolchain
+	_, compilerErr := exec.LookPath(options.Compiler)
+
+	// The archs are checked against the ones the compiler supports. If it can't list them,
+	// they are left for the compiler to report.
+	var targets []string
+	if compilerErr == nil {
+		targets, _ = goTargets(options)
+	}
+	if !lo.Contains(supportedPlatforms, options.Platform) {
+		problems = append(problems, fmt.Sprintf("platform '%s' is not supported. Supported platforms: %s", options.Platform, strings.Join(supportedPlatforms, ", ")))
+	} else if options.Arch != "" && len(targets) > 0 {
+		archs := platformArchs(targets, options.Platform)
+		for _, arch := range strings.Split(options.Arch, ",") {
+			if !lo.Contains(archs, strings.TrimSpace(arch)) {
+				problems = append(problems, fmt.Sprintf("arch '%s' is not supported for platform '%s'. Supported archs: %s", arch, options.Platform, strings.Join(archs, ", ")))
+			}
+		}
+	}
+
+	if compilerErr != nil {
+		problems = append(problems, fmt.Sprintf("compiler '%s' not found or not executable", options.Compiler))
+	}
+
+	for _, cgo := range []struct{ name, compiler string }{{"C", options.CC}, {"C++", options.CXX}} {
+		if cgo.compiler == "" {
+			continue
+		}
+		for _, arch := range compiledArchs(options) {
+			command := strings.Fields(cgoCompiler(cgo.compiler, arch))
+			if len(command) == 0 {
+				continue
+			}
+			if _, err := exec.LookPath(command[0]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s compiler '%s' not found or not executable", cgo.name, command[0]))
+			}
+		}
+	}
+
+	if options.EntryPoint != "" {
+		if !fs.DirExists(mainPackageDir(options)) {
+			problems = append(problems, fmt.Sprintf("entry point '%s' does not exist", options.EntryPoint))
+		}
+	}
+
+	if options.PGOProfile != "" && options.PGOProfile != PGOAuto && !fs.FileExists(pgoProfile(options)) {
+		problems = append(problems, fmt.Sprintf("PGO profile '%s' does not exist", options.PGOProfile))
+	}
+
+	problems = append(problems, validateLinuxFileModes(options)...)
+	problems = append(problems, validateOutputNameTemplate(options)...)
+
+	linkVars := make([]string, 0, len(options.LinkVars))
+	for name := range options.LinkVars {
+		linkVars = append(linkVars, name)
+	}
+	sort.Strings(linkVars)
+	for _, name := range linkVars {
+		if _, err := linkVarFlag(name, options.LinkVars[name]); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	ldflagsTargets := make([]string, 0, len(options.PlatformLDFlags))
+	for target := range options.PlatformLDFlags {
+		ldflagsTargets = append(ldflagsTargets, target)
+	}
+	sort.Strings(ldflagsTargets)
+	for _, target := range ldflagsTargets {
+		platform, arch, hasArch := strings.Cut(target, "/")
+		supported := lo.Contains(supportedPlatforms, platform)
+		if hasArch && arch != "*" && len(targets) > 0 && !lo.Contains(platformArchs(targets, platform), arch) {
+			supported = false
+		}
+		if !supported {
+			problems = append(problems, fmt.Sprintf("invalid ldflags target '%s': must be a supported GOOS, GOOS/* or GOOS/GOARCH", target))
+		}
+	}
+
+	verbosityPhases := make([]string, 0, len(options.PhaseVerbosity))
+	for phase := range options.PhaseVerbosity {
+		verbosityPhases = append(verbosityPhases, phase)
+	}
+	sort.Strings(verbosityPhases)
+	for _, phase := range verbosityPhases {
+		if !lo.Contains(buildPhases, phase) {
+			problems = append(problems, fmt.Sprintf("unknown build phase '%s' for verbosity: must be one of %s", phase, strings.Join(buildPhases, ", ")))
+		} else if verbosity := options.PhaseVerbosity[phase]; verbosity < 0 || verbosity > VERBOSE {
+			problems = append(problems, fmt.Sprintf("invalid verbosity %d for the %s phase: must be 0, 1 or 2", verbosity, phase))
+		}
+	}
+
+	if options.CleanBackupsToKeep < 0 {
+		problems = append(problems, "the number of bin directory backups to keep must not be negative")
+	} else if options.CleanBackupsToKeep > 0 && !options.BackupBeforeClean {
+		problems = append(problems, "a number of bin directory backups to keep can only be given when backing up before cleaning")
+	}
+
+	if options.CompileParallelism < 0 {
+		problems = append(problems, "the number of parallel compile jobs must not be negative")
+	}
+
+	if options.BuildInfoVarPrefix != "" && !options.InjectBuildInfo {
+		problems = append(problems, "a build info variable prefix can only be used when injecting the build info")
+	}
+
+	iconFiles := map[string]string{
+		"darwin":  options.MacIconFile,
+		"windows": options.WindowsIconFile,
+		"linux":   options.LinuxIconFile,
+	}
+	for _, platform := range []string{"darwin", "windows", "linux"} {
+		if iconFiles[platform] == "" {
+			continue
+		}
+		if err := validateIconFile(platformIconFile(options, platform), platform); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if options.MacMinVersion != "" {
+		if !macVersionRegex.MatchString(options.MacMinVersion) {
+			problems = append(problems, fmt.Sprintf("invalid macOS minimum version '%s': must be in the form 10.15 or 11.0.1", options.MacMinVersion))
+		} else if semver.MustParse(options.MacMinVersion).LessThan(semver.MustParse(defaultMacMinVersion)) {
+			problems = append(problems, fmt.Sprintf("invalid macOS minimum version '%s': Wails applications require macOS %s or later", options.MacMinVersion, defaultMacMinVersion))
+		}
+	}
+
+	plistKeys := make([]string, 0, len(options.MacPlistExtras))
+	for key := range options.MacPlistExtras {
+		plistKeys = append(plistKeys, key)
+	}
+	sort.Strings(plistKeys)
+	for _, key := range plistKeys {
+		if key == "" {
+			problems = append(problems, "invalid Info.plist extra key: keys must not be empty")
+		} else if _, err := encodePListValue(options.MacPlistExtras[key], ""); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid Info.plist value for '%s': %s", key, err))
+		}
+	}
+
+	if options.PrebuiltFrontendDir != "" {
+		if !options.IgnoreFrontend {
+			problems = append(problems, "a prebuilt frontend can only be used when not building the frontend")
+		}
+		if !fs.DirExists(prebuiltFrontendDir(options)) {
+			problems = append(problems, fmt.Sprintf("prebuilt frontend directory '%s' does not exist", options.PrebuiltFrontendDir))
+		} else if empty, err := fs.DirIsEmpty(prebuiltFrontendDir(options)); err == nil && empty {
+			problems = append(problems, fmt.Sprintf("prebuilt frontend directory '%s' is empty", options.PrebuiltFrontendDir))
+		}
+	}
+
+	if options.ZipBundle && !options.Pack {
+		problems = append(problems, "zipping the .app bundle requires packaging the application")
+	}
+
+	if options.NotarizeProfile != "" && options.MacSigningIdentity == "" {
+		problems = append(problems, "notarization requires a macOS signing identity")
+	}
+
+	if options.GPGSigningKey != "" && !options.GenerateChecksums {
+		problems = append(problems, "signing checksums requires generating checksums")
+	}
+
+	if options.StripSymbols && options.RaceDetector {
+		problems = append(problems, "cannot strip symbols when building with the race detector")
+	}
+
+	if options.RaceDetector {
+		for _, arch := range compiledArchs(options) {
+			if target := options.Platform + "/" + arch; !lo.Contains(raceDetectorTargets, target) {
+				problems = append(problems, fmt.Sprintf("the race detector is not supported for %s", target))
+			}
+		}
+	}
+
+	if options.CGOEnabled != nil && !*options.CGOEnabled {
+		if options.RaceDetector {
+			problems = append(problems, "the race detector requires CGO")
+		}
+		if options.isSharedLibrary() {
+			problems = append(problems, "building a shared library requires CGO")
+		}
+	}
+
+	if options.AMD64Level != "" && !lo.Contains([]string{"v1", "v2", "v3", "v4"}, options.AMD64Level) {
+		problems = append(problems, fmt.Sprintf("invalid AMD64 level '%s': must be one of v1, v2, v3 or v4", options.AMD64Level))
+	}
+
+	if options.GOARM != "" && !lo.Contains([]string{"5", "6", "7"}, options.GOARM) {
+		problems = append(problems, fmt.Sprintf("invalid GOARM version '%s': must be one of 5, 6 or 7", options.GOARM))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid build options:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
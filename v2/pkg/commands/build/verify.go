@@ -0,0 +1,109 @@
+package build
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+)
+
+var elfMachines = map[string]elf.Machine{
+	"amd64": elf.EM_X86_64,
+	"arm64": elf.EM_AARCH64,
+	"arm":   elf.EM_ARM,
+	"386":   elf.EM_386,
+}
+
+var machoCPUs = map[string]macho.Cpu{
+	"amd64": macho.CpuAmd64,
+	"arm64": macho.CpuArm64,
+}
+
+var peMachines = map[string]uint16{
+	"amd64": pe.IMAGE_FILE_MACHINE_AMD64,
+	"arm64": pe.IMAGE_FILE_MACHINE_ARM64,
+	"386":   pe.IMAGE_FILE_MACHINE_I386,
+}
+
+// verifyBinary checks that the given file is an executable for the given platform and arch
+// by parsing its header. The binary is never executed as Wails applications are GUI applications.
+func verifyBinary(filename string, platform string, arch string) error {
+	var err error
+	switch platform {
+	case "windows":
+		err = verifyPE(filename, arch)
+	case "darwin":
+		err = verifyMachO(filename, arch)
+	default:
+		err = verifyELF(filename, arch)
+	}
+	if err != nil {
+		return fmt.Errorf("verification of '%s' failed: %w", filename, err)
+	}
+	return nil
+}
+
+func verifyELF(filename string, arch string) error {
+	f, err := elf.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if f.Type != elf.ET_EXEC && f.Type != elf.ET_DYN {
+		return fmt.Errorf("not an executable: %s", f.Type)
+	}
+	if expected, ok := elfMachines[arch]; ok && f.Machine != expected {
+		return fmt.Errorf("expected machine %s, got %s", expected, f.Machine)
+	}
+	return nil
+}
+
+func verifyMachO(filename string, arch string) error {
+	if arch == "universal" {
+		f, err := macho.OpenFat(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		for _, expected := range []macho.Cpu{macho.CpuAmd64, macho.CpuArm64} {
+			found := false
+			for _, slice := range f.Arches {
+				if slice.Cpu == expected {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("universal binary is missing the %s slice", expected)
+			}
+		}
+		return nil
+	}
+
+	f, err := macho.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if f.Type != macho.TypeExec && f.Type != macho.TypeDylib {
+		return fmt.Errorf("not an executable: %s", f.Type)
+	}
+	if expected, ok := machoCPUs[arch]; ok && f.Cpu != expected {
+		return fmt.Errorf("expected cpu %s, got %s", expected, f.Cpu)
+	}
+	return nil
+}
+
+func verifyPE(filename string, arch string) error {
+	f, err := pe.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if f.Characteristics&pe.IMAGE_FILE_EXECUTABLE_IMAGE == 0 {
+		return fmt.Errorf("not an executable image")
+	}
+	if expected, ok := peMachines[arch]; ok && f.Machine != expected {
+		return fmt.Errorf("expected machine 0x%x, got 0x%x", expected, f.Machine)
+	}
+	return nil
+}
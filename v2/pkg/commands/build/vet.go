@@ -0,0 +1,44 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runVet runs `go vet ./...` on the project with the build tags and target platform of the build.
+// Universal and multi-arch builds are vetted for a single architecture, as the sources rarely differ.
+func runVet(options *Options) error {
+	arch := strings.Split(options.Arch, ",")[0]
+	if arch == "universal" {
+		arch = "arm64"
+	}
+
+	args := []string{"vet", "-tags", buildTags(options), "./..."}
+	cmd := exec.CommandContext(options.buildContext(), options.Compiler, args...)
+	cmd.Dir = options.ProjectData.Path
+	cmd.Env = upsertEnv(os.Environ(), "GOOS", func(v string) string {
+		return options.Platform
+	})
+	cmd.Env = upsertEnv(cmd.Env, "GOARCH", func(v string) string {
+		return arch
+	})
+	cmd.Env = applyGoEnv(options, cmd.Env)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if options.verbosity() == VERBOSE {
+		options.Logger.Println("\n  Vet command: %s %s", options.Compiler, strings.Join(args, " "))
+	}
+	err := cmd.Run()
+	if options.verbosity() == VERBOSE && output.Len() > 0 {
+		options.Logger.Println("%s", strings.TrimSpace(output.String()))
+	}
+	if err != nil {
+		return fmt.Errorf("go vet reported problems: %w\n%s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
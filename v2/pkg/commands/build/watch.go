@@ -0,0 +1,210 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/lo"
+	"github.com/wailsapp/wails/v2/internal/fs"
+)
+
+// defaultWatchDebounce is how long Watch waits after the last change before rebuilding, if the project gives no DebounceMS
+const defaultWatchDebounce = 100 * time.Millisecond
+
+// installLockFiles are the files the frontend install rewrites during a build, so they don't trigger rebuilds
+var installLockFiles = []string{"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb"}
+
+// watchOutputDirs returns the directories a build writes to, which Watch ignores: the build directory,
+// the generated wailsjs directories and, if the frontend is watched, the embedded directory the built
+// frontend goes in
+func watchOutputDirs(options *Options) []string {
+	projectData := options.ProjectData
+	bindingsOptions := *options
+	if bindingsOptions.WailsJSDir == "" {
+		bindingsOptions.WailsJSDir = projectData.GetWailsJSDir()
+	}
+	outputDirs := []string{
+		projectData.GetBuildDir(),
+		filepath.Join(bindingsOptions.WailsJSDir, "wailsjs"),
+		filepath.Join(bindingsOutputDir(&bindingsOptions), "wailsjs"),
+	}
+	if !options.IgnoreFrontend {
+		// Without an embedded frontend directory there is no built frontend to ignore
+		if embedDir, err := frontendEmbedDir(options); err == nil {
+			outputDirs = append(outputDirs, embedDir)
+		}
+	}
+	return outputDirs
+}
+
+// watchFilter decides which directories Watch watches and which changes trigger a rebuild
+type watchFilter struct {
+	frontendDir    string
+	ignoreFrontend bool
+	outputDirs     []string
+}
+
+// ignored returns true if the directory isn't watched: hidden directories, node_modules and the output directories
+func (w *watchFilter) ignored(dir string) bool {
+	name := filepath.Base(dir)
+	if (strings.HasPrefix(name, ".") && name != ".") || name == "node_modules" {
+		return true
+	}
+	for _, outputDir := range w.outputDirs {
+		if isWithinDir(dir, outputDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// triggers returns true if a change to the file should rebuild: Go sources and modules or, unless
+// the frontend is ignored, frontend files other than the install lock files
+func (w *watchFilter) triggers(filename string) bool {
+	if w.ignored(filename) || w.ignored(filepath.Dir(filename)) {
+		return false
+	}
+	if name := filepath.Base(filename); filepath.Ext(name) == ".go" || name == "go.mod" || name == "go.sum" {
+		return true
+	}
+	if w.ignoreFrontend || !isWithinDir(filename, w.frontendDir) || lo.Contains(installLockFiles, filepath.Base(filename)) {
+		return false
+	}
+	return true
+}
+
+// Watch rebuilds the project with Build whenever the Go sources in the given directories, or the
+// project directory if none are given, change. The frontend sources are watched too unless
+// IgnoreFrontend is set. Subdirectories are watched, except hidden ones, node_modules and the
+// directories a build writes to: the build directory, the generated wailsjs directories and the
+// embedded frontend directory. Rewrites of the install lock files don't trigger rebuilds either.
+//
+// Changes are debounced by the project's DebounceMS and rebuilds never overlap: a change during a build
+// triggers another one once it is done. Each rebuild uses a copy of the given options and its result is
+// passed to onRebuild. Nothing is built until something changes.
+// The returned stop function stops watching, cancels a running build and waits for it to finish.
+func Watch(options *Options, paths []string, onRebuild func(binary string, err error)) (stop func(), err error) {
+	projectData := options.ProjectData
+	if len(paths) == 0 {
+		paths = []string{projectData.Path}
+	}
+	if !options.IgnoreFrontend {
+		paths = append(paths, projectData.GetFrontendDir())
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	filter := &watchFilter{
+		frontendDir:    projectData.GetFrontendDir(),
+		ignoreFrontend: options.IgnoreFrontend,
+		outputDirs:     watchOutputDirs(options),
+	}
+	addDirectory := func(dir string) error {
+		return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return err
+			}
+			if path != dir && filter.ignored(path) {
+				return filepath.SkipDir
+			}
+			return watcher.Add(path)
+		})
+	}
+	for _, path := range paths {
+		if !fs.DirExists(path) {
+			continue
+		}
+		if err := addDirectory(path); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+
+	debounceTime := defaultWatchDebounce
+	if projectData.DebounceMS > 0 {
+		debounceTime = time.Duration(projectData.DebounceMS) * time.Millisecond
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var building sync.Mutex
+	var rebuilds sync.WaitGroup
+	rebuild := func() {
+		defer rebuilds.Done()
+		building.Lock()
+		defer building.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		buildOptions := *options
+		binary, err := BuildWithContext(ctx, &buildOptions)
+		if ctx.Err() != nil {
+			return
+		}
+		onRebuild(binary, err)
+	}
+
+	// Each change restarts the debounce timer. A rebuild is pending from the timer being started
+	// until its rebuild returns, so stop can wait for it
+	var timerLock sync.Mutex
+	var timer *time.Timer
+	stopped := false
+	scheduleRebuild := func() {
+		timerLock.Lock()
+		defer timerLock.Unlock()
+		if stopped {
+			return
+		}
+		if timer != nil && timer.Stop() {
+			rebuilds.Done()
+		}
+		rebuilds.Add(1)
+		timer = time.AfterFunc(debounceTime, rebuild)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create != 0 && fs.DirExists(event.Name) && !filter.ignored(event.Name) {
+					_ = addDirectory(event.Name)
+				}
+				if event.Op&fsnotify.Chmod == 0 && filter.triggers(event.Name) {
+					scheduleRebuild()
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			timerLock.Lock()
+			stopped = true
+			if timer != nil && timer.Stop() {
+				rebuilds.Done()
+			}
+			timerLock.Unlock()
+			cancel()
+			_ = watcher.Close()
+			<-done
+			rebuilds.Wait()
+		})
+	}
+	return stop, nil
+}